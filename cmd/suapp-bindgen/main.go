@@ -0,0 +1,74 @@
+// Command suapp-bindgen generates typed Go bindings for contracts compiled
+// with forge.
+//
+//	suapp-bindgen -out bindings optimism-builder.sol/OpBuilder.json
+//
+// Each artifact is written to <out>/<package>/<package>.go, with the package
+// named after the contract.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/flashbots/suapp-examples/framework"
+	"github.com/flashbots/suapp-examples/framework/bindgen"
+)
+
+func main() {
+	artifactsDir := flag.String("artifacts", framework.DefaultArtifactsDir(), "directory the artifact paths are relative to")
+	outDir := flag.String("out", "bindings", "directory to write the bindings to")
+	pkg := flag.String("pkg", "", "package name, only with a single artifact (default: the lower cased contract name)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] artifact...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 || (*pkg != "" && flag.NArg() > 1) {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	loader := framework.NewArtifactLoaderDir(*artifactsDir)
+	for _, path := range flag.Args() {
+		if err := generate(loader, path, *outDir, *pkg); err != nil {
+			fmt.Fprintf(os.Stderr, "suapp-bindgen: %s: %v\n", path, err)
+			os.Exit(1)
+		}
+	}
+}
+
+func generate(loader *framework.ArtifactLoader, path, outDir, pkg string) error {
+	artifact, err := loader.Read(path)
+	if err != nil {
+		return err
+	}
+
+	name := contractName(path)
+	if pkg == "" {
+		pkg = bindgen.PackageName(name)
+	}
+	code, err := bindgen.Generate(pkg, name, path, artifact.Abi)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(outDir, pkg)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, pkg+".go"), code, 0o644)
+}
+
+// contractName returns the contract of an artifact path, which is either the
+// ":Name" suffix or the file name, e.g. OpBuilder for "x.sol/OpBuilder.json".
+func contractName(path string) string {
+	if i := strings.LastIndex(path, ":"); i >= 0 {
+		return path[i+1:]
+	}
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
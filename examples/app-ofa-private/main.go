@@ -8,7 +8,6 @@ import (
 	"net/http"
 	"net/http/httptest"
 
-	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/flashbots/suapp-examples/framework"
@@ -20,6 +19,7 @@ func main() {
 
 	fr := framework.New()
 	contract := fr.DeployContract("ofa-private.sol/OFAPrivate.json")
+	events := framework.NewEventDecoderABI(contract.ABI())
 
 	// Step 1. Create and fund the accounts we are going to frontrun/backrun
 	fmt.Println("1. Create and fund test accounts")
@@ -63,7 +63,7 @@ func main() {
 	receipt := contractAddr1.SendTransaction("newOrder", []interface{}{}, bundleBytes)
 
 	hintEvent := &HintEvent{}
-	if err := hintEvent.Unpack(receipt.Logs[0]); err != nil {
+	if err := events.DecodeEvent(receipt.Logs[0], "HintEvent", hintEvent); err != nil {
 		panic(err)
 	}
 
@@ -83,7 +83,7 @@ func main() {
 	receipt = contractAddr2.SendTransaction("newMatch", []interface{}{hintEvent.BidId}, backRunBundleBytes)
 
 	matchEvent := &HintEvent{}
-	if err := matchEvent.Unpack(receipt.Logs[0]); err != nil {
+	if err := events.DecodeEvent(receipt.Logs[0], "HintEvent", matchEvent); err != nil {
 		panic(err)
 	}
 
@@ -95,28 +95,11 @@ func main() {
 	contract.SendTransaction("emitMatchBidAndHint", []interface{}{fakeRelayer.URL, matchEvent.BidId}, backRunBundleBytes)
 }
 
-var hintEventABI abi.Event
-
-func init() {
-	artifact, _ := framework.ReadArtifact("ofa-private.sol/OFAPrivate.json")
-	hintEventABI = artifact.Abi.Events["HintEvent"]
-}
-
 type HintEvent struct {
-	BidId [16]byte
+	BidId [16]byte `abi:"id"`
 	Hint  []byte
 }
 
-func (h *HintEvent) Unpack(log *types.Log) error {
-	unpacked, err := hintEventABI.Inputs.Unpack(log.Data)
-	if err != nil {
-		return err
-	}
-	h.BidId = unpacked[0].([16]byte)
-	h.Hint = unpacked[1].([]byte)
-	return nil
-}
-
 type relayHandlerExample struct {
 }
 
@@ -8,11 +8,8 @@ import (
 	"math/big"
 	"time"
 
-	"github.com/ethereum/go-ethereum"
-	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/flashbots/suapp-examples/framework"
 )
 
@@ -39,9 +36,8 @@ func main() {
 	})
 
 	log.Println("2. Start off-chain actors")
-	ethClient, _ := ethclient.Dial("ws://127.0.0.1:8546")
 	go SearcherLoop(
-		ethClient,
+		fr,
 		ofaContract,
 		testAddr2,
 		testAddr1.Address())
@@ -75,55 +71,61 @@ func main() {
 // Off-chain Actors code
 
 func SearcherLoop(
-	client *ethclient.Client,
+	fr *framework.Framework,
 	ofaContract *framework.Contract,
 	searcher *framework.PrivKey,
 	beneficiary common.Address,
 ) {
-	fr := framework.New()
-	query := ethereum.FilterQuery{
-		Addresses: []common.Address{ofaContract.Address()},
-	}
-	logs := make(chan types.Log)
-	sub, err := client.SubscribeFilterLogs(context.Background(), query, logs)
-	if err != nil {
-		log.Fatal("[SEARCHER] Create logs filter: ", err)
-	}
-
+	events := framework.NewEventDecoderABI(ofaContract.ABI())
 	ofaSearcher := ofaContract.Ref(searcher)
 	knownBids := map[types.BidId]struct{}{}
 
-	log.Println("[SEARCHER] Start listen to events")
-	for {
-		select {
-		case err := <-sub.Err():
-			log.Fatal("[SEARCHER] Subscription: ", err)
-		case vLog := <-logs:
-			hintEvent := &HintEvent{}
-			if err := hintEvent.Unpack(&vLog); err != nil {
-				log.Println("WARN[SEARCHER]: Failed to unpack hint event: ", err)
-				break
-			}
-			if _, ok := knownBids[hintEvent.BidId]; ok {
-				log.Println("[SEARCHER] Already known bid", hintEvent.BidId)
-				break
-			}
-
-			log.Println("[SEARCHER] Hint event received, id:", hintEvent.BidId)
-			log.Println("[SEARCHER] Send backrun")
-			backRunBundleBytes := createBackrunBundle(fr, searcher, beneficiary)
-
-			// backrun inputs
-			receipt := ofaSearcher.SendTransaction(
-				"newMatch", []interface{}{hintEvent.BidId}, backRunBundleBytes)
-
-			matchEvent := &HintEvent{}
-			if err := matchEvent.Unpack(receipt.Logs[0]); err != nil {
-				log.Fatalf("Failed to unpack match event: %s", err)
-			}
-			knownBids[matchEvent.BidId] = struct{}{}
+	handleHint := func(ctx context.Context, event *framework.Event) error {
+		hintEvent := &HintEvent{}
+		if err := event.Decode(hintEvent); err != nil {
+			return err
 		}
+		if _, ok := knownBids[hintEvent.BidId]; ok {
+			log.Println("[SEARCHER] Already known bid", hintEvent.BidId)
+			return nil
+		}
+
+		log.Println("[SEARCHER] Hint event received, id:", hintEvent.BidId)
+		log.Println("[SEARCHER] Send backrun")
+		backRunBundleBytes := createBackrunBundle(fr, searcher, beneficiary)
+
+		// backrun inputs
+		receipt, err := ofaSearcher.SendTransactionContext(ctx,
+			"newMatch", []interface{}{hintEvent.BidId}, backRunBundleBytes)
+		if err != nil {
+			return err
+		}
+
+		matchEvent := &HintEvent{}
+		if err := events.DecodeEvent(receipt.Logs[0], "HintEvent", matchEvent); err != nil {
+			return err
+		}
+		knownBids[matchEvent.BidId] = struct{}{}
+		return nil
+	}
+
+	router := framework.NewRouter()
+	err := router.Handle(ofaContract, "HintEvent", handleHint,
+		framework.WithErrorPolicy(framework.SkipOnError),
+		framework.OnHandlerError(func(event *framework.Event, err error) {
+			log.Println("WARN[SEARCHER]: ", err)
+		}),
+	)
+	if err != nil {
+		log.Println("ERROR[SEARCHER]: Register hint handler: ", err)
+		return
 	}
+
+	log.Println("[SEARCHER] Start listen to events")
+	err = router.Run(context.Background(), framework.OnConnectionError(func(err error, retryIn time.Duration) {
+		log.Printf("WARN[SEARCHER]: Subscription failed, reconnecting in %s: %s", retryIn, err)
+	}))
+	log.Println("ERROR[SEARCHER]: Stopped listening to events: ", err)
 }
 
 func BuilderLoop(
@@ -164,16 +166,6 @@ func BuilderLoop(
 
 // Structs and helpers
 
-var hintEventABI abi.Event
-
-func init() {
-	artifact, err := framework.ReadArtifact("mev-boost.sol/OFAPrivate.json")
-	if err != nil {
-		log.Panic("Failed to read artifact: ", err)
-	}
-	hintEventABI = artifact.Abi.Events["HintEvent"]
-}
-
 type BuildBlockArgs struct {
 	Slot           uint64
 	ProposerPubkey []byte
@@ -192,20 +184,10 @@ type BuildBlockArgs struct {
 }
 
 type HintEvent struct {
-	BidId [16]byte
+	BidId [16]byte `abi:"id"`
 	Hint  []byte
 }
 
-func (h *HintEvent) Unpack(log *types.Log) error {
-	unpacked, err := hintEventABI.Inputs.Unpack(log.Data)
-	if err != nil {
-		return err
-	}
-	h.BidId = unpacked[0].([16]byte)
-	h.Hint = unpacked[1].([]byte)
-	return nil
-}
-
 func createBackrunBundle(fr *framework.Framework, searcher *framework.PrivKey, beneficiary common.Address) []byte {
 	ethTxnBackrun, _ := fr.SignTx(searcher, &types.LegacyTx{
 		To:       &beneficiary,
@@ -3,23 +3,22 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
-	"github.com/ethereum/go-ethereum"
-	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/flashbots/suapp-examples/framework"
 	"github.com/sirupsen/logrus"
 )
 
 const (
-	SuaveNodeRpc             = "ws://127.0.0.1:11546"
 	ContractAddrEnv          = "CONTRACT_ADDR"
 	NewBundleEventName       = "NewBundleEvent"
 	NewBuilderBidEventName   = "NewBuilderBidEvent"
 	ContractAbiJsonPath      = "optimism-builder.sol/OpBuilder.json"
+	ContractName             = "OpBuilder"
 	ContractBuildBlockMethod = "buildBlock"
 	ContractPostBlockMethod  = "submitBlock"
 
@@ -36,155 +35,138 @@ var (
 )
 
 type EventListener struct {
-	ethclient          *ethclient.Client
-	opEthClient        *ethclient.Client
-	log                *logrus.Entry
-	contractAddr       common.Address
-	artifact           *framework.Artifact
-	bundleEventAbi     abi.Event
-	builderBidEventAbi abi.Event
+	fr           *framework.Framework
+	opEthClient  *ethclient.Client
+	network      *framework.Network
+	log          *logrus.Entry
+	contractAddr common.Address
+	artifact     *framework.Artifact
 }
 
-func NewEventListener(log *logrus.Entry, contractAddress common.Address) (*EventListener, error) {
-	ethClient, err := ethclient.Dial(SuaveNodeRpc)
+func NewEventListener(log *logrus.Entry, fr *framework.Framework, contractAddress common.Address) (*EventListener, error) {
+	network := fr.Network()
+	opEthClient, err := fr.Client(context.Background(), framework.OpChain)
 	if err != nil {
 		return nil, err
 	}
 
-	opEthClient, err := ethclient.Dial("http://localhost:9545")
-	if err != nil {
-		return nil, err
-	}
-
-	artifact, err := framework.ReadArtifact(ContractAbiJsonPath)
+	artifact, err := fr.ReadArtifact(ContractAbiJsonPath)
 	if err != nil {
 		return nil, errArtifactRead
 	}
-	bundleEventAbi := artifact.Abi.Events[NewBundleEventName]
-	builderBidEventAbi := artifact.Abi.Events[NewBuilderBidEventName]
 
 	return &EventListener{
-		log:                log,
-		ethclient:          ethClient,
-		opEthClient:        opEthClient,
-		contractAddr:       contractAddress,
-		artifact:           artifact,
-		bundleEventAbi:     bundleEventAbi,
-		builderBidEventAbi: builderBidEventAbi,
+		log:          log,
+		fr:           fr,
+		opEthClient:  opEthClient,
+		network:      network,
+		contractAddr: contractAddress,
+		artifact:     artifact,
 	}, nil
 }
 
 func (el *EventListener) Listen() {
-	el.log.Println("Start listen to events", "RPC", SuaveNodeRpc, "contract", el.contractAddr.Hex())
-	query := ethereum.FilterQuery{
-		Addresses: []common.Address{el.contractAddr},
+	el.log.Println("Start listen to events", "RPC", el.fr.Config().KettleWS, "contract", el.contractAddr.Hex())
+	contract := el.fr.ContractAt(el.contractAddr, el.artifact.Abi)
+
+	router := framework.NewRouter()
+	routeOpts := []framework.RouteOption{
+		// the handlers send confidential requests, which a retry after a
+		// receipt timeout would run again, building or submitting twice
+		framework.WithErrorPolicy(framework.SkipOnError),
+		framework.WithRevertHandler(el.onRevert),
+		framework.OnHandlerError(func(event *framework.Event, err error) {
+			el.log.WithError(err).WithField("tx", event.Log.TxHash).Warn("Failed to handle event")
+		}),
 	}
-	logs := make(chan types.Log)
-	sub, err := el.ethclient.SubscribeFilterLogs(context.Background(), query, logs)
-	if err != nil {
-		el.log.Fatal("Create logs filter: ", err)
+	if err := router.Handle(contract, NewBundleEventName, el.onBundle, routeOpts...); err != nil {
+		el.log.WithError(err).Error("Register bundle handler")
+		return
+	}
+	if err := router.Handle(contract, NewBuilderBidEventName, el.onBuilderBid, routeOpts...); err != nil {
+		el.log.WithError(err).Error("Register builder bid handler")
+		return
+	}
+
+	err := router.Run(
+		context.Background(),
+		// resume after the last handled event when restarted
+		framework.WithCursor(framework.NewFileCursorStore(framework.DefaultCursorPath()), ""),
+		framework.OnConnectionError(func(err error, retryIn time.Duration) {
+			el.log.WithError(err).Warnf("Subscription failed, reconnecting in %s", retryIn)
+		}),
+	)
+	el.log.WithError(err).WithField("metrics", router.Metrics()).Error("Stopped listening to events")
+}
+
+func (el *EventListener) onBundle(ctx context.Context, event *framework.Event) error {
+	bidEvent := &BundleEvent{}
+	if err := event.Decode(bidEvent); err != nil {
+		el.log.Warn("Failed to unpack bundle event: ", err)
+		return nil
 	}
+	el.log.Printf("Got bid event %+v", bidEvent)
+	return el.TriggerBlockBuild(ctx, bidEvent.BidId, bidEvent.DecryptCond)
+}
 
-	bundleEventSig := []byte("NewBundleEvent(bytes16,uint64,address[])")
-	bundleEventSigHash := crypto.Keccak256Hash(bundleEventSig)
-	builderBidEventSig := []byte("NewBuilderBidEvent(bytes16,uint64,address[],bytes)")
-	builderBidEventSigHash := crypto.Keccak256Hash(builderBidEventSig)
-
-	for {
-		select {
-		case err := <-sub.Err():
-			el.log.Fatal("Subscription: ", err)
-		case vLog := <-logs:
-			if vLog.Topics[0] == bundleEventSigHash {
-				el.log.Printf("Got bid event")
-				bidEvent := &BundleEvent{}
-				if err := bidEvent.Unpack(&vLog, el.bundleEventAbi); err != nil {
-					el.log.Warn("Failed to unpack hint event: ", err)
-					break
-				}
-				el.log.Printf("%+v\n", bidEvent)
-				el.TriggerBlockBuild(bidEvent.BidId, bidEvent.decryptCond)
-			} else if vLog.Topics[0] == builderBidEventSigHash {
-				el.log.Printf("Got builder bid event")
-				builderBidEvent := &BuilderBidEvent{}
-				if err := builderBidEvent.Unpack(&vLog, el.builderBidEventAbi); err != nil {
-					el.log.Warn("Failed to unpack hint event: ", err)
-					break
-				}
-				el.log.Printf("%+v\n", builderBidEvent)
-				el.SubmitBlock(builderBidEvent.BidId, "http://host.docker.internal:18550")
-			} else {
-				el.log.Warn("Unknown event: ", vLog.Topics[0].Hex())
-			}
-		}
+func (el *EventListener) onBuilderBid(ctx context.Context, event *framework.Event) error {
+	builderBidEvent := &BuilderBidEvent{}
+	if err := event.Decode(builderBidEvent); err != nil {
+		el.log.Warn("Failed to unpack builder bid event: ", err)
+		return nil
 	}
+	el.log.Printf("Got builder bid event %+v", builderBidEvent)
+	return el.SubmitBlock(ctx, builderBidEvent.BidId, el.network.RelayURL())
+}
+
+// onRevert is called for events removed by a reorg after being handled, which
+// SUAPP_CONFIRMATIONS makes less likely.
+func (el *EventListener) onRevert(ctx context.Context, event *framework.Event) error {
+	// both events start with the data id
+	bidEvent := &BundleEvent{}
+	if err := event.Decode(bidEvent); err != nil {
+		return nil
+	}
+	el.log.WithField("block", event.Log.BlockNumber).Warnf("%s %x removed by a reorg", event.Name, bidEvent.BidId)
+	return nil
 }
 
 // Assumes Builder account is funded, see `BuilderAddr` in constants
-func (el *EventListener) TriggerBlockBuild(bidId types.BidId, decryptCond uint64) error {
-	fr := framework.New()
+func (el *EventListener) TriggerBlockBuild(ctx context.Context, bidId types.BidId, decryptCond uint64) error {
 	builder := framework.NewPrivKeyFromHex(BuilderPrivKey)
 
-	ctrct := fr.ContractAt(el.contractAddr, el.artifact.Abi)
+	ctrct := el.fr.ContractAt(el.contractAddr, el.artifact.Abi)
 	builderCtrct := ctrct.Ref(builder)
 
 	el.log.Info("Trigger block build for block ", decryptCond, " with bid ", bidId)
-	receipt := builderCtrct.SendTransaction(ContractBuildBlockMethod, []interface{}{decryptCond}, []byte("hello"))
-
-	if receipt.Status == 0 {
-		return errUnsuccessfulTx
+	if _, err := builderCtrct.SendTransactionContext(ctx, ContractBuildBlockMethod, []interface{}{decryptCond}, []byte("hello")); err != nil {
+		return fmt.Errorf("%w: %w", errUnsuccessfulTx, err)
 	}
-
 	return nil
 }
 
-func (el *EventListener) SubmitBlock(bidId types.BidId, url string) error {
-	fr := framework.New()
+func (el *EventListener) SubmitBlock(ctx context.Context, bidId types.BidId, url string) error {
 	builder := framework.NewPrivKeyFromHex(BuilderPrivKey)
 
-	ctrct := fr.ContractAt(el.contractAddr, el.artifact.Abi)
+	ctrct := el.fr.ContractAt(el.contractAddr, el.artifact.Abi)
 	builderCtrct := ctrct.Ref(builder)
 
 	el.log.Info("Submit block ", url, " with bid ", bidId)
-	receipt := builderCtrct.SendTransaction(ContractPostBlockMethod, []interface{}{bidId, url}, []byte("hello"))
-	if receipt.Status == 0 {
-		return errSubmitBlock
+	if _, err := builderCtrct.SendTransactionContext(ctx, ContractPostBlockMethod, []interface{}{bidId, url}, []byte("hello")); err != nil {
+		return fmt.Errorf("%w: %w", errSubmitBlock, err)
 	}
 	return nil
 }
 
 type BundleEvent struct {
-	BidId       [16]byte
-	decryptCond uint64
-	peekers     []common.Address
-}
-
-func (h *BundleEvent) Unpack(log *types.Log, eventAbi abi.Event) error {
-	unpacked, err := eventAbi.Inputs.Unpack(log.Data)
-	if err != nil {
-		return err
-	}
-	h.BidId = unpacked[0].([16]byte)
-	h.decryptCond = unpacked[1].(uint64)
-	h.peekers = unpacked[2].([]common.Address)
-	return nil
+	BidId       [16]byte         `abi:"dataId"`
+	DecryptCond uint64           `abi:"decryptionCondition"`
+	Peekers     []common.Address `abi:"allowedPeekers"`
 }
 
 type BuilderBidEvent struct {
-	BidId       [16]byte
-	decryptCond uint64
-	peekers     []common.Address
-	envelope    []byte
-}
-
-func (h *BuilderBidEvent) Unpack(log *types.Log, eventAbi abi.Event) error {
-	unpacked, err := eventAbi.Inputs.Unpack(log.Data)
-	if err != nil {
-		return err
-	}
-	h.BidId = unpacked[0].([16]byte)
-	h.decryptCond = unpacked[1].(uint64)
-	h.peekers = unpacked[2].([]common.Address)
-	h.envelope = unpacked[3].([]byte)
-	return nil
+	BidId       [16]byte         `abi:"dataId"`
+	DecryptCond uint64           `abi:"decryptionCondition"`
+	Peekers     []common.Address `abi:"allowedPeekers"`
+	Envelope    []byte           `abi:"envelope"`
 }
@@ -10,7 +10,6 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/flashbots/suapp-examples/framework"
 	"github.com/sirupsen/logrus"
 )
@@ -18,7 +17,6 @@ import (
 const (
 	LogLevel               = "debug"
 	OpDevAccountPrivKeyHex = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
-	OpChainId              = 901
 )
 
 func main() {
@@ -37,10 +35,18 @@ func main() {
 	balance, err := fr.Balance(common.HexToAddress("0xb5feafbdd752ad52afb7e1bd2e40432a485bbb7f"))
 	fmt.Printf("Balance of account 0xb5feafbdd752ad52afb7e1bd2e40432a485bbb7f: %d\n", balance)
 
-	contract := fr.DeployContract("optimism-builder.sol/OpBuilder.json")
-	log.Infof("contract deployed at address: %s", contract.Address())
+	// reuse the contract of a previous run, so that the listener resumes from
+	// its cursor, unless the artifact or the chain changed since
+	contract, err := deployedContract(fr)
+	if err != nil {
+		log.WithError(err).Info("deploying a new contract")
+		contract = fr.DeployContract(ContractAbiJsonPath)
+		log.Infof("contract deployed at address: %s", contract.Address())
+	} else {
+		log.Infof("contract found at address: %s", contract.Address())
+	}
 
-	evListSrv, err := NewEventListener(log, contract.Address())
+	evListSrv, err := NewEventListener(log, fr, contract.Address())
 	if err != nil {
 		log.WithError(err).Fatal("failed creating the event listener")
 	}
@@ -61,7 +67,10 @@ func main() {
 
 	log.Info("2. Send transaction")
 
-	client, err := ethclient.Dial("http://localhost:9545")
+	client, err := fr.Client(context.Background(), framework.OpChain)
+	if err != nil {
+		log.Fatal(err)
+	}
 	OpDevAccountPrivKey := framework.NewPrivKeyFromHex(OpDevAccountPrivKeyHex)
 	ephemeralAddr := framework.GeneratePrivKey().Address()
 
@@ -73,10 +82,18 @@ func main() {
 
 	nonce, err := client.NonceAt(context.Background(), OpDevAccountPrivKey.Address(), big.NewInt(int64(blkNo)))
 
-	opTxn1 := types.NewTransaction(nonce, ephemeralAddr, big.NewInt(1000), 21000, big.NewInt(13), nil)
-
-	opTxn1Signed, err := types.SignTx(opTxn1, types.NewEIP155Signer(big.NewInt(OpChainId)), OpDevAccountPrivKey.Priv)
+	opSigner, err := fr.Signer(context.Background(), framework.OpChain)
+	if err != nil {
+		log.Fatal(err)
+	}
 
+	opTxn1Signed, err := opSigner.SignTx(OpDevAccountPrivKey, &types.LegacyTx{
+		Nonce:    nonce,
+		To:       &ephemeralAddr,
+		Value:    big.NewInt(1000),
+		Gas:      21000,
+		GasPrice: big.NewInt(13),
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -102,3 +119,24 @@ func main() {
 		}
 	}
 }
+
+// deployedContract returns the contract recorded in the manifest if its code
+// is still on chain.
+func deployedContract(fr *framework.Framework) (*framework.Contract, error) {
+	contract, err := fr.ContractByName(ContractName)
+	if err != nil {
+		return nil, err
+	}
+	client, err := fr.Client(context.Background(), framework.SuaveChain)
+	if err != nil {
+		return nil, err
+	}
+	code, err := client.CodeAt(context.Background(), contract.Address(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) == 0 {
+		return nil, fmt.Errorf("no code at %s", contract.Address())
+	}
+	return contract, nil
+}
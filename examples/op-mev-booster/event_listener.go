@@ -3,22 +3,21 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
+	"time"
 
-	"github.com/ethereum/go-ethereum"
-	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/flashbots/suapp-examples/framework"
 	"github.com/sirupsen/logrus"
 )
 
 const (
-	SuaveNodeRpc            = "ws://127.0.0.1:8546"
 	ContractAddrEnv         = "CONTRACT_ADDR"
-	EventTypeName           = "BuilderBidEvent"
+	EventTypeName           = "NewBuilderBidEvent"
 	ContractAbiJsonPath     = "optimism-builder.sol/OpBuilder.json"
+	ContractName            = "OpBuilder"
 	ContractPostBlockMethod = "postBlockToRelay"
 
 	// BuilderPrivKey Builder address "0xDceef22333b11aD2CAb54Be2A8ECe08EE64D919C" needs to be funded
@@ -26,103 +25,113 @@ const (
 )
 
 var (
-	errMissingContractAddr = errors.New("missing contract address to listen events for")
-	errArtifactRead        = errors.New("failed to read artifact from " + ContractAbiJsonPath)
-	errUnsuccessfulTx      = errors.New("unsuccessful transaction to " + ContractPostBlockMethod)
+	errArtifactRead   = errors.New("failed to read artifact from " + ContractAbiJsonPath)
+	errUnsuccessfulTx = errors.New("unsuccessful transaction to " + ContractPostBlockMethod)
 )
 
 type EventListener struct {
-	ethclient    *ethclient.Client
+	fr           *framework.Framework
 	log          *logrus.Entry
 	contractAddr common.Address
 	artifact     *framework.Artifact
-	eventAbi     abi.Event
+	events       *framework.EventDecoder
 }
 
 func NewEventListener(log *logrus.Entry) (*EventListener, error) {
-	ethClient, err := ethclient.Dial(SuaveNodeRpc)
+	cfg, err := framework.LoadConfig("")
 	if err != nil {
 		return nil, err
 	}
 
-	addrHex := os.Getenv(ContractAddrEnv)
-	if addrHex == "" {
-		return nil, errMissingContractAddr
+	fr, err := framework.NewWithConfig(cfg)
+	if err != nil {
+		return nil, err
 	}
-	contractAddr := common.HexToAddress(addrHex)
 
-	artifact, err := framework.ReadArtifact(ContractAbiJsonPath)
+	artifact, err := fr.ReadArtifact(ContractAbiJsonPath)
 	if err != nil {
 		return nil, errArtifactRead
 	}
-	eventAbi := artifact.Abi.Events[EventTypeName]
+
+	// without an explicit address, use the contract deployed by op-build-trigger
+	contractAddr := common.HexToAddress(os.Getenv(ContractAddrEnv))
+	if contractAddr == (common.Address{}) {
+		contract, err := fr.ContractByName(ContractName)
+		if err != nil {
+			return nil, err
+		}
+		contractAddr = contract.Address()
+	}
 
 	return &EventListener{
 		log:          log,
-		ethclient:    ethClient,
+		fr:           fr,
 		contractAddr: contractAddr,
 		artifact:     artifact,
-		eventAbi:     eventAbi,
+		events:       framework.NewEventDecoder(artifact),
 	}, nil
 }
 
 func (el *EventListener) Listen() {
-	el.log.Println("Start listen to events", "RPC", SuaveNodeRpc, "contract", el.contractAddr.Hex())
-	query := ethereum.FilterQuery{
-		Addresses: []common.Address{el.contractAddr},
-	}
-	logs := make(chan types.Log)
-	sub, err := el.ethclient.SubscribeFilterLogs(context.Background(), query, logs)
+	el.log.Println("Start listen to events", "RPC", el.fr.Config().KettleWS, "contract", el.contractAddr.Hex())
+	contract := el.fr.ContractAt(el.contractAddr, el.artifact.Abi)
+	sub, err := contract.NewSubscriber(
+		[]string{EventTypeName},
+		framework.OnConnectionError(func(err error, retryIn time.Duration) {
+			el.log.WithError(err).Warnf("Subscription failed, reconnecting in %s", retryIn)
+		}),
+	)
 	if err != nil {
-		el.log.Fatal("Create logs filter: ", err)
+		el.log.WithError(err).Error("Create logs subscriber")
+		return
 	}
 
-	for {
-		select {
-		case err := <-sub.Err():
-			el.log.Fatal("Subscription: ", err)
-		case vLog := <-logs:
-			event := &BuilderBidEvent{}
-			if err := event.Unpack(&vLog, el.eventAbi); err != nil {
-				el.log.Warn("Failed to unpack hint event: ", err)
-				break
-			}
-
-			el.log.WithField("Event", event).Println("Event received, id:", event.BidId)
-			el.SendPostBlockToRelay(event.BidId)
-		}
+	if err := sub.Run(context.Background(), el); err != nil {
+		el.log.WithError(err).Error("Stopped listening to events")
 	}
 }
 
-// Assumes Builder account is funded, see `BuilderAddr` in constants
-func (el *EventListener) SendPostBlockToRelay(builderBid types.BidId) error {
-	fr := framework.New()
+// HandleLog acts on the events once they have enough confirmations.
+func (el *EventListener) HandleLog(ctx context.Context, vLog types.Log) error {
+	event := &BuilderBidEvent{}
+	if err := el.events.DecodeEvent(&vLog, EventTypeName, event); err != nil {
+		el.log.Warn("Failed to unpack builder bid event: ", err)
+		return nil
+	}
+
+	el.log.WithField("Event", event).Println("Event received, id:", event.BidId)
+	// a failed post is not resent, as the kettle would post the block again,
+	// nor returned, as it would stop the subscriber
+	if err := el.SendPostBlockToRelay(ctx, event.BidId); err != nil {
+		el.log.WithError(err).WithField("tx", vLog.TxHash).Warn("Failed to post block to relay")
+	}
+	return nil
+}
 
+// RevertLog is called for builder bids removed by a reorg after being posted.
+func (el *EventListener) RevertLog(ctx context.Context, vLog types.Log) error {
+	event := &BuilderBidEvent{}
+	if err := el.events.DecodeEvent(&vLog, EventTypeName, event); err != nil {
+		return nil
+	}
+	el.log.WithField("block", vLog.BlockNumber).Warn("Builder bid removed by a reorg, id:", event.BidId)
+	return nil
+}
+
+// Assumes Builder account is funded, see `BuilderAddr` in constants
+func (el *EventListener) SendPostBlockToRelay(ctx context.Context, builderBid types.BidId) error {
 	builder := framework.NewPrivKeyFromHex(BuilderPrivKey)
 
-	ctrct := fr.ContractAt(el.contractAddr, el.artifact.Abi)
+	ctrct := el.fr.ContractAt(el.contractAddr, el.artifact.Abi)
 	builderCtrct := ctrct.Ref(builder)
 
-	receipt := builderCtrct.SendTransaction(ContractPostBlockMethod, []interface{}{DefaultListenAddr, builderBid}, nil)
-
-	if receipt.Status == 0 {
-		return errUnsuccessfulTx
+	if _, err := builderCtrct.SendTransactionContext(ctx, ContractPostBlockMethod, []interface{}{DefaultListenAddr, builderBid}, nil); err != nil {
+		return fmt.Errorf("%w: %w", errUnsuccessfulTx, err)
 	}
-
 	return nil
 }
 
 type BuilderBidEvent struct {
-	BidId      [16]byte
-	BuilderBid []byte
-}
-
-func (h *BuilderBidEvent) Unpack(log *types.Log, eventAbi abi.Event) error {
-	unpacked, err := eventAbi.Inputs.Unpack(log.Data)
-	if err != nil {
-		return err
-	}
-	h.BidId = unpacked[0].([16]byte)
-	h.BuilderBid = unpacked[1].([]byte)
-	return nil
+	BidId      [16]byte `abi:"dataId"`
+	BuilderBid []byte   `abi:"envelope"`
 }
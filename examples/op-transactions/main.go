@@ -1,6 +1,7 @@
 package main
 
-// NOTE: Run it with contract address passed as an env variable:
+// NOTE: The contract deployed by op-build-trigger is used by default, another
+// one can be passed as an env variable:
 // CONTRACT_ADDR=0xd594760B2A36467ec7F0267382564772D7b0b73c go run .
 
 import (
@@ -15,8 +16,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/rpc"
-	"github.com/ethereum/go-ethereum/suave/sdk"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/flashbots/suapp-examples/framework"
 	"github.com/sirupsen/logrus"
 )
@@ -24,9 +24,6 @@ import (
 const (
 	LogLevel = "debug"
 
-	// GethNodeRpc RPC endpoint is the pointing to op-geth node
-	GethNodeRpc = "http://localhost:8545"
-
 	// PrivateKeyHex [OP chain] of the 1st of prefunded accounts from pbs-on-optimism, address 0x1023e8DbDebAd480C43f6e19b3381c465c74E933
 	PrivateKeyHex = "f513f6c8a938fb611fa6589400fba7f1d00cad4ec0972b5bfe38657f26d1b0fc"
 	// BuilderPrivKey [Suave chain] Builder address "0xDceef22333b11aD2CAb54Be2A8ECe08EE64D919C" needs to be funded
@@ -34,29 +31,33 @@ const (
 
 	ContractAddrEnv     = "CONTRACT_ADDR"
 	ContractAbiJsonPath = "optimism-builder.sol/OpBuilder.json"
+	ContractName        = "OpBuilder"
 	ContractNewTxMethod = "newTx"
 )
 
 var (
 	BundlePushIntervalSeconds = time.Duration(5 * time.Second)
 
-	errMissingContractAddr = errors.New("missing contract address to listen events for")
-	errArtifactRead        = errors.New("failed to read artifact from " + ContractAbiJsonPath)
-	errUnsuccessfulTx      = errors.New("unsuccessful transaction to " + ContractNewTxMethod)
-	errOpGethConnection    = errors.New("failed to connect to op-geth node")
-	errNonceFetch          = errors.New("failed getting nonce from op-geth node")
+	errArtifactRead     = errors.New("failed to read artifact from " + ContractAbiJsonPath)
+	errUnsuccessfulTx   = errors.New("unsuccessful transaction to " + ContractNewTxMethod)
+	errOpGethConnection = errors.New("failed to connect to op-geth node")
+	errNonceFetch       = errors.New("failed getting nonce from op-geth node")
 )
 
 type AccountTransfer struct {
 	Address common.Address
 	key     *framework.PrivKey
-	client  *sdk.Client
+	client  *ethclient.Client
+	signer  *framework.ChainSigner
 	log     *logrus.Entry
 
+	// nonces hands out the nonces of Address on the chain of signer
+	nonces *framework.NonceManager
+
 	// Tx creation params
 	txInBundle       uint8
 	amount, gasPrice *big.Int
-	nonce, gasLimit  uint64
+	gasLimit         uint64
 }
 
 func main() {
@@ -70,11 +71,23 @@ func main() {
 	}
 	log.Logger.SetLevel(lvl)
 
-	at, err := NewAccountTransfer(log, GethNodeRpc, PrivateKeyHex)
+	cfg, err := framework.LoadConfig("")
+	if err != nil {
+		log.WithError(err).Fatal("failed loading the framework config")
+	}
+
+	fr, err := framework.NewWithConfig(cfg)
+	if err != nil {
+		log.WithError(err).Fatal("failed connecting to the kettle")
+	}
+	defer fr.Close()
+
+	// the execution node of the network receives the bundled transactions
+	at, err := NewAccountTransfer(log, fr, PrivateKeyHex)
 	if err != nil {
 		log.Fatal("failed creating the account transfer")
 	}
-	bb, err := NewBuilderRef(log)
+	bb, err := NewBuilderRef(log, fr)
 	if err != nil {
 		log.Fatal("failed creating the builder reference")
 	}
@@ -99,32 +112,37 @@ func main() {
 	}
 }
 
-func NewAccountTransfer(log *logrus.Entry, rpcUrl, key string) (*AccountTransfer, error) {
-	rpcClient, err := rpc.Dial(rpcUrl)
+func NewAccountTransfer(log *logrus.Entry, fr *framework.Framework, key string) (*AccountTransfer, error) {
+	ctx := context.Background()
+	client, err := fr.Client(ctx, framework.ExecutionChain)
 	if err != nil {
 		log.WithError(err).Error("failed to connect to op-geth node")
 		return nil, errOpGethConnection
 	}
+	signer, err := fr.Signer(ctx, framework.ExecutionChain)
+	if err != nil {
+		log.WithError(err).Error("failed getting the chain id")
+		return nil, errOpGethConnection
+	}
 
 	privKey := framework.NewPrivKeyFromHex(key)
 	addr := crypto.PubkeyToAddress(privKey.Priv.PublicKey)
-	sdkClient := sdk.NewClient(rpcClient, privKey.Priv, common.Address{})
 
-	rpc := sdkClient.RPC()
-	nonce, err := rpc.PendingNonceAt(context.Background(), addr)
-	if err != nil {
+	nonces := framework.DefaultNonceManager()
+	if err := nonces.Resync(ctx, client, signer.ChainID(), addr); err != nil {
 		log.WithError(err).Error("failed getting nonce")
 		return nil, errNonceFetch
 	}
-	log.WithField("nonce", nonce).WithField("address", addr.Hex()).Info("Initialized")
+	log.WithField("address", addr.Hex()).Info("Initialized")
 
 	return &AccountTransfer{
 		Address: addr,
 		key:     privKey,
-		client:  sdkClient,
+		client:  client,
+		signer:  signer,
 		log:     log,
 
-		nonce: nonce,
+		nonces: nonces,
 
 		// some artificial numbers to start with
 		txInBundle: 10,
@@ -135,28 +153,41 @@ func NewAccountTransfer(log *logrus.Entry, rpcUrl, key string) (*AccountTransfer
 	}, nil
 }
 
-func (at *AccountTransfer) createTx() (*types.Transaction, error) {
+func (at *AccountTransfer) createTx(ctx context.Context) (*types.Transaction, error) {
+	nonce, err := at.nonces.Next(ctx, at.client, at.signer.ChainID(), at.Address)
+	if err != nil {
+		at.log.WithError(err).Error("failed getting nonce")
+		return nil, errNonceFetch
+	}
+
 	txn := &types.LegacyTx{
 		To:       &at.Address,
 		Value:    at.amount,
 		GasPrice: at.gasPrice,
 		Gas:      at.gasLimit,
-		Nonce:    at.nonce,
+		Nonce:    nonce,
 	}
 
-	tx, err := at.client.SignTxn(txn)
+	tx, err := at.signer.SignTx(at.key, txn)
 	if err != nil {
+		at.nonces.Release(at.signer.ChainID(), at.Address, nonce)
 		at.log.WithError(err).Error("failed to sign transaction")
 		return nil, err
 	}
 
-	// remember to increment nonce
-	at.nonce++
-
 	return tx, nil
 }
 
 func (at *AccountTransfer) createBundle() (*types.SBundle, error) {
+	ctx := context.Background()
+
+	// the bundled transactions only reach the pool once a block includes them,
+	// start again from the chain so that dropped bundles leave no nonce gap
+	if err := at.nonces.Resync(ctx, at.client, at.signer.ChainID(), at.Address); err != nil {
+		at.log.WithError(err).Error("failed getting nonce")
+		return nil, errNonceFetch
+	}
+
 	// TODO: Is it the exact bundle type we have in op-builder?
 	bundle := &types.SBundle{
 		Txs:             make([]*types.Transaction, at.txInBundle),
@@ -164,7 +195,7 @@ func (at *AccountTransfer) createBundle() (*types.SBundle, error) {
 	}
 
 	for i := uint8(0); i < at.txInBundle; i++ {
-		tx, err := at.createTx()
+		tx, err := at.createTx(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -175,24 +206,29 @@ func (at *AccountTransfer) createBundle() (*types.SBundle, error) {
 }
 
 type BuilderRef struct {
+	fr           *framework.Framework
 	log          *logrus.Entry
 	contractAddr common.Address
 	artifact     *framework.Artifact
 }
 
-func NewBuilderRef(log *logrus.Entry) (*BuilderRef, error) {
-	artifact, err := framework.ReadArtifact(ContractAbiJsonPath)
+func NewBuilderRef(log *logrus.Entry, fr *framework.Framework) (*BuilderRef, error) {
+	artifact, err := fr.ReadArtifact(ContractAbiJsonPath)
 	if err != nil {
 		return nil, errArtifactRead
 	}
 
-	addrHex := os.Getenv(ContractAddrEnv)
-	if addrHex == "" {
-		return nil, errMissingContractAddr
+	contractAddr := common.HexToAddress(os.Getenv(ContractAddrEnv))
+	if contractAddr == (common.Address{}) {
+		contract, err := fr.ContractByName(ContractName)
+		if err != nil {
+			return nil, err
+		}
+		contractAddr = contract.Address()
 	}
-	contractAddr := common.HexToAddress(addrHex)
 
 	return &BuilderRef{
+		fr:           fr,
 		log:          log,
 		contractAddr: contractAddr,
 		artifact:     artifact,
@@ -200,11 +236,9 @@ func NewBuilderRef(log *logrus.Entry) (*BuilderRef, error) {
 }
 
 func (bb *BuilderRef) SendBundle(blkHeight int, bundle []byte) error {
-	fr := framework.New()
-
 	builder := framework.NewPrivKeyFromHex(BuilderPrivKey)
 
-	ctrct := fr.ContractAt(bb.contractAddr, bb.artifact.Abi)
+	ctrct := bb.fr.ContractAt(bb.contractAddr, bb.artifact.Abi)
 	builderCtrct := ctrct.Ref(builder)
 
 	bb.log.WithField("bundle", blkHeight).Info("Sending bundle")
@@ -0,0 +1,211 @@
+package framework
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrInvalidArtifact is matched by errors for artifacts that cannot be decoded.
+var ErrInvalidArtifact = errors.New("invalid artifact")
+
+type Artifact struct {
+	Abi *abi.ABI
+
+	// Code is the code to deploy the contract
+	Code []byte
+
+	// DeployedCode is the runtime code of the contract, if the artifact has it
+	DeployedCode []byte
+
+	// LinkReferences locate the unlinked libraries in Code and
+	// DeployedLinkReferences those in DeployedCode. Placeholders are zeroed.
+	LinkReferences         LinkReferences
+	DeployedLinkReferences LinkReferences
+}
+
+// LinkReferences maps a library to the byte offsets of the 20 byte
+// placeholders for its address. Libraries are keyed by their fully qualified
+// name ("file.sol:Lib") or, when the artifact only has the solc placeholder,
+// by "$<placeholder hash>$".
+type LinkReferences map[string][]int
+
+// ReadArtifact reads an artifact from DefaultArtifactsDir.
+func ReadArtifact(path string) (*Artifact, error) {
+	return NewArtifactLoaderDir(DefaultArtifactsDir()).Read(path)
+}
+
+// DefaultArtifactsDir returns the directory named by SUAPP_ARTIFACTS_DIR or,
+// if unset, the Foundry "out" directory of the working directory. When the
+// working directory has none, it falls back to the "out" directory of this
+// checkout, which only exists when running from source.
+func DefaultArtifactsDir() string {
+	if dir := os.Getenv(EnvArtifactsDir); dir != "" {
+		return dir
+	}
+	if info, err := os.Stat("out"); err == nil && info.IsDir() {
+		return "out"
+	}
+	if _, filename, _, ok := runtime.Caller(0); ok {
+		return filepath.Join(filepath.Dir(filename), "../out")
+	}
+	return "out"
+}
+
+// ArtifactLoader reads contract artifacts from a file system.
+type ArtifactLoader struct {
+	fsys fs.FS
+}
+
+// NewArtifactLoader returns a loader reading from fsys, e.g. an embed.FS.
+func NewArtifactLoader(fsys fs.FS) *ArtifactLoader {
+	return &ArtifactLoader{fsys: fsys}
+}
+
+// NewArtifactLoaderDir returns a loader reading from the directory dir.
+func NewArtifactLoaderDir(dir string) *ArtifactLoader {
+	return NewArtifactLoader(os.DirFS(dir))
+}
+
+// Read reads and decodes the artifact at path, relative to the loader root,
+// e.g. "ofa-private.sol/OFAPrivate.json". Outputs bundling several contracts
+// select one with a ":Name" or ":file.sol:Name" suffix, e.g.
+// "combined.json:OFAPrivate".
+func (l *ArtifactLoader) Read(path string) (*Artifact, error) {
+	path = filepath.ToSlash(strings.TrimPrefix(path, "./"))
+
+	// the contract may be fully qualified, so the file ends at the first colon
+	var contract string
+	if i := strings.Index(path, ":"); i >= 0 {
+		path, contract = path[:i], path[i+1:]
+	}
+
+	data, err := fs.ReadFile(l.fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact %s: %w", path, err)
+	}
+
+	artifact, err := DecodeArtifactContract(data, contract)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return artifact, nil
+}
+
+// DecodeArtifact decodes an artifact in any of the registered formats.
+// Formats bundling several contracts must hold exactly one.
+func DecodeArtifact(data []byte) (*Artifact, error) {
+	return DecodeArtifactContract(data, "")
+}
+
+// DecodeArtifactContract is like DecodeArtifact but selects the contract
+// named contract ("Name" or "file.sol:Name") from multi-contract outputs.
+func DecodeArtifactContract(data []byte, contract string) (*Artifact, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArtifact, err)
+	}
+
+	for _, decoder := range artifactDecoders {
+		if decoder.Detect(raw) {
+			artifact, err := decoder.Decode(raw, contract)
+			if err != nil {
+				return nil, fmt.Errorf("%s artifact: %w", decoder.Name(), err)
+			}
+			return artifact, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown artifact format", ErrInvalidArtifact)
+}
+
+// decodeBytecode decodes a hex bytecode string. Unlinked library placeholders
+// are replaced with zero addresses and their offsets returned by placeholder.
+func decodeBytecode(object string, prefixed bool) ([]byte, map[string][]int, error) {
+	if prefixed {
+		if !strings.HasPrefix(object, "0x") {
+			return nil, nil, fmt.Errorf("%w: bytecode is not 0x prefixed", ErrInvalidArtifact)
+		}
+		object = object[2:]
+	}
+	if object == "" {
+		return nil, nil, fmt.Errorf("%w: empty bytecode", ErrInvalidArtifact)
+	}
+
+	var placeholders map[string][]int
+	for {
+		i := strings.Index(object, "__")
+		if i < 0 {
+			break
+		}
+		if i%2 != 0 || len(object) < i+40 {
+			return nil, nil, fmt.Errorf("%w: malformed library placeholder at %d", ErrInvalidArtifact, i/2)
+		}
+		if placeholders == nil {
+			placeholders = map[string][]int{}
+		}
+		placeholder := strings.Trim(object[i:i+40], "_")
+		placeholders[placeholder] = append(placeholders[placeholder], i/2)
+		object = object[:i] + strings.Repeat("0", 40) + object[i+40:]
+	}
+
+	code, err := hex.DecodeString(object)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: bytecode: %w", ErrInvalidArtifact, err)
+	}
+	return code, placeholders, nil
+}
+
+// Link returns a copy of Code with the library addresses written over the
+// placeholders. libraries are keyed by "Lib" or by "file.sol:Lib", and
+// "$hash$" placeholders only match the latter, as the hash is taken over the
+// fully qualified name.
+func (a *Artifact) Link(libraries map[string]common.Address) ([]byte, error) {
+	code := common.CopyBytes(a.Code)
+	for ref, offsets := range a.LinkReferences {
+		addr, ok := lookupLibrary(ref, libraries)
+		if !ok && strings.HasPrefix(ref, "$") {
+			return nil, fmt.Errorf("%w: unlinked library placeholder %s, link it by its fully qualified name \"file.sol:Lib\"", ErrInvalidArtifact, ref)
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: unlinked library %s", ErrInvalidArtifact, ref)
+		}
+		for _, offset := range offsets {
+			if offset < 0 || offset+common.AddressLength > len(code) {
+				return nil, fmt.Errorf("%w: link reference of %s out of bounds", ErrInvalidArtifact, ref)
+			}
+			copy(code[offset:], addr.Bytes())
+		}
+	}
+	return code, nil
+}
+
+// lookupLibrary finds the address for a link reference key, which is a fully
+// qualified name or a "$hash$" solc placeholder.
+func lookupLibrary(ref string, libraries map[string]common.Address) (common.Address, bool) {
+	if addr, ok := libraries[ref]; ok {
+		return addr, true
+	}
+	for name, addr := range libraries {
+		if strings.HasPrefix(ref, "$") {
+			// the placeholder is the first 34 hex chars of keccak256(fully qualified name)
+			if ref == "$"+hex.EncodeToString(crypto.Keccak256([]byte(name)))[:34]+"$" {
+				return addr, true
+			}
+			continue
+		}
+		if !strings.Contains(name, ":") && ref[strings.LastIndex(ref, ":")+1:] == name {
+			return addr, true
+		}
+	}
+	return common.Address{}, false
+}
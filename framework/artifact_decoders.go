@@ -0,0 +1,352 @@
+package framework
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// ArtifactDecoder decodes one artifact format into an Artifact.
+type ArtifactDecoder interface {
+	// Name identifies the format in errors.
+	Name() string
+	// Detect reports whether the top level fields of an artifact are in this format.
+	Detect(raw map[string]json.RawMessage) bool
+	// Decode decodes the artifact, selecting contract from multi-contract outputs.
+	Decode(raw map[string]json.RawMessage, contract string) (*Artifact, error)
+}
+
+// artifactDecoders are tried in order until one detects the format.
+var artifactDecoders = []ArtifactDecoder{
+	foundryDecoder{},
+	hardhatDecoder{},
+	combinedJSONDecoder{},
+	standardJSONDecoder{},
+}
+
+// RegisterArtifactDecoder adds a decoder tried before the built-in ones.
+func RegisterArtifactDecoder(d ArtifactDecoder) {
+	artifactDecoders = append([]ArtifactDecoder{d}, artifactDecoders...)
+}
+
+// foundryDecoder decodes the artifacts written to out/ by forge build.
+type foundryDecoder struct{}
+
+func (foundryDecoder) Name() string { return "foundry" }
+
+func (foundryDecoder) Detect(raw map[string]json.RawMessage) bool {
+	return isJSONObject(raw["bytecode"])
+}
+
+func (foundryDecoder) Decode(raw map[string]json.RawMessage, _ string) (*Artifact, error) {
+	type bytecode struct {
+		Object         *string            `json:"object"`
+		LinkReferences solcLinkReferences `json:"linkReferences"`
+	}
+	var artifact struct {
+		Abi              *abi.ABI  `json:"abi"`
+		Bytecode         *bytecode `json:"bytecode"`
+		DeployedBytecode *bytecode `json:"deployedBytecode"`
+	}
+	if err := unmarshalRaw(raw, &artifact); err != nil {
+		return nil, err
+	}
+	if artifact.Bytecode == nil || artifact.Bytecode.Object == nil {
+		return nil, fmt.Errorf("%w: missing bytecode.object", ErrInvalidArtifact)
+	}
+
+	res := &Artifact{Abi: artifact.Abi}
+	var err error
+	if res.Code, res.LinkReferences, err = decodeLinkedBytecode(*artifact.Bytecode.Object, true, artifact.Bytecode.LinkReferences); err != nil {
+		return nil, err
+	}
+	if artifact.DeployedBytecode != nil && artifact.DeployedBytecode.Object != nil {
+		if res.DeployedCode, res.DeployedLinkReferences, err = decodeOptionalBytecode(*artifact.DeployedBytecode.Object, true, artifact.DeployedBytecode.LinkReferences); err != nil {
+			return nil, err
+		}
+	}
+	return res, validateArtifact(res)
+}
+
+// hardhatDecoder decodes the hh-sol-artifact files written to artifacts/ by Hardhat.
+type hardhatDecoder struct{}
+
+func (hardhatDecoder) Name() string { return "hardhat" }
+
+func (hardhatDecoder) Detect(raw map[string]json.RawMessage) bool {
+	_, ok := raw["_format"]
+	return ok || isJSONString(raw["bytecode"])
+}
+
+func (hardhatDecoder) Decode(raw map[string]json.RawMessage, _ string) (*Artifact, error) {
+	var artifact struct {
+		Abi                    *abi.ABI           `json:"abi"`
+		Bytecode               *string            `json:"bytecode"`
+		DeployedBytecode       *string            `json:"deployedBytecode"`
+		LinkReferences         solcLinkReferences `json:"linkReferences"`
+		DeployedLinkReferences solcLinkReferences `json:"deployedLinkReferences"`
+	}
+	if err := unmarshalRaw(raw, &artifact); err != nil {
+		return nil, err
+	}
+	if artifact.Bytecode == nil {
+		return nil, fmt.Errorf("%w: missing bytecode", ErrInvalidArtifact)
+	}
+
+	res := &Artifact{Abi: artifact.Abi}
+	var err error
+	if res.Code, res.LinkReferences, err = decodeLinkedBytecode(*artifact.Bytecode, true, artifact.LinkReferences); err != nil {
+		return nil, err
+	}
+	if artifact.DeployedBytecode != nil {
+		if res.DeployedCode, res.DeployedLinkReferences, err = decodeOptionalBytecode(*artifact.DeployedBytecode, true, artifact.DeployedLinkReferences); err != nil {
+			return nil, err
+		}
+	}
+	return res, validateArtifact(res)
+}
+
+// combinedJSONDecoder decodes the output of solc --combined-json abi,bin,bin-runtime.
+type combinedJSONDecoder struct{}
+
+func (combinedJSONDecoder) Name() string { return "solc combined-json" }
+
+func (combinedJSONDecoder) Detect(raw map[string]json.RawMessage) bool {
+	contracts, ok := decodeContracts(raw)
+	if !ok {
+		return false
+	}
+	for name := range contracts {
+		if strings.Contains(name, ":") {
+			return true
+		}
+	}
+	return false
+}
+
+func (combinedJSONDecoder) Decode(raw map[string]json.RawMessage, contract string) (*Artifact, error) {
+	contracts, _ := decodeContracts(raw)
+	name, err := selectContract(contracts, contract)
+	if err != nil {
+		return nil, err
+	}
+
+	var output struct {
+		Abi        json.RawMessage `json:"abi"`
+		Bin        *string         `json:"bin"`
+		BinRuntime *string         `json:"bin-runtime"`
+	}
+	if err := json.Unmarshal(contracts[name], &output); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidArtifact, name, err)
+	}
+
+	// solc before 0.8 encodes the abi as a JSON string
+	abiJSON := output.Abi
+	if isJSONString(abiJSON) {
+		var s string
+		if err := json.Unmarshal(abiJSON, &s); err != nil {
+			return nil, fmt.Errorf("%w: %s: abi: %w", ErrInvalidArtifact, name, err)
+		}
+		abiJSON = json.RawMessage(s)
+	}
+	res := &Artifact{}
+	if len(abiJSON) != 0 {
+		parsed, err := abi.JSON(bytes.NewReader(abiJSON))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: abi: %w", ErrInvalidArtifact, name, err)
+		}
+		res.Abi = &parsed
+	}
+
+	if output.Bin == nil {
+		return nil, fmt.Errorf("%w: %s: missing bin", ErrInvalidArtifact, name)
+	}
+	if res.Code, res.LinkReferences, err = decodeLinkedBytecode(*output.Bin, false, nil); err != nil {
+		return nil, err
+	}
+	if output.BinRuntime != nil {
+		if res.DeployedCode, res.DeployedLinkReferences, err = decodeOptionalBytecode(*output.BinRuntime, false, nil); err != nil {
+			return nil, err
+		}
+	}
+	return res, validateArtifact(res)
+}
+
+// standardJSONDecoder decodes the output of solc --standard-json.
+type standardJSONDecoder struct{}
+
+func (standardJSONDecoder) Name() string { return "solc standard-json" }
+
+func (standardJSONDecoder) Detect(raw map[string]json.RawMessage) bool {
+	_, ok := decodeContracts(raw)
+	return ok
+}
+
+func (standardJSONDecoder) Decode(raw map[string]json.RawMessage, contract string) (*Artifact, error) {
+	files, _ := decodeContracts(raw)
+
+	// flatten the file -> name -> output structure to "file:name" -> output
+	contracts := map[string]json.RawMessage{}
+	for file, data := range files {
+		var byName map[string]json.RawMessage
+		if err := json.Unmarshal(data, &byName); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidArtifact, file, err)
+		}
+		for name, output := range byName {
+			contracts[file+":"+name] = output
+		}
+	}
+	name, err := selectContract(contracts, contract)
+	if err != nil {
+		return nil, err
+	}
+
+	type bytecode struct {
+		Object         *string            `json:"object"`
+		LinkReferences solcLinkReferences `json:"linkReferences"`
+	}
+	var output struct {
+		Abi *abi.ABI `json:"abi"`
+		Evm struct {
+			Bytecode         *bytecode `json:"bytecode"`
+			DeployedBytecode *bytecode `json:"deployedBytecode"`
+		} `json:"evm"`
+	}
+	if err := json.Unmarshal(contracts[name], &output); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidArtifact, name, err)
+	}
+	if output.Evm.Bytecode == nil || output.Evm.Bytecode.Object == nil {
+		return nil, fmt.Errorf("%w: %s: missing evm.bytecode.object", ErrInvalidArtifact, name)
+	}
+
+	res := &Artifact{Abi: output.Abi}
+	if res.Code, res.LinkReferences, err = decodeLinkedBytecode(*output.Evm.Bytecode.Object, false, output.Evm.Bytecode.LinkReferences); err != nil {
+		return nil, err
+	}
+	if deployed := output.Evm.DeployedBytecode; deployed != nil && deployed.Object != nil {
+		if res.DeployedCode, res.DeployedLinkReferences, err = decodeOptionalBytecode(*deployed.Object, false, deployed.LinkReferences); err != nil {
+			return nil, err
+		}
+	}
+	return res, validateArtifact(res)
+}
+
+// solcLinkReferences is the file -> library -> offsets structure solc,
+// Foundry and Hardhat use for link references.
+type solcLinkReferences map[string]map[string][]struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
+}
+
+func (s solcLinkReferences) flatten() LinkReferences {
+	if len(s) == 0 {
+		return nil
+	}
+	refs := LinkReferences{}
+	for file, libs := range s {
+		for lib, offsets := range libs {
+			for _, offset := range offsets {
+				refs[file+":"+lib] = append(refs[file+":"+lib], offset.Start)
+			}
+		}
+	}
+	return refs
+}
+
+// decodeLinkedBytecode decodes bytecode and its link references. Without
+// explicit references, the placeholders found in the bytecode are used.
+func decodeLinkedBytecode(object string, prefixed bool, refs solcLinkReferences) ([]byte, LinkReferences, error) {
+	code, placeholders, err := decodeBytecode(object, prefixed)
+	if err != nil {
+		return nil, nil, err
+	}
+	if linkRefs := refs.flatten(); linkRefs != nil {
+		return code, linkRefs, nil
+	}
+	return code, LinkReferences(placeholders), nil
+}
+
+// decodeOptionalBytecode is like decodeLinkedBytecode but accepts an empty
+// bytecode, as found for the runtime code of abstract contracts.
+func decodeOptionalBytecode(object string, prefixed bool, refs solcLinkReferences) ([]byte, LinkReferences, error) {
+	if object == "" || object == "0x" {
+		return nil, nil, nil
+	}
+	return decodeLinkedBytecode(object, prefixed, refs)
+}
+
+func validateArtifact(artifact *Artifact) error {
+	if artifact.Abi == nil {
+		return fmt.Errorf("%w: missing abi", ErrInvalidArtifact)
+	}
+	return nil
+}
+
+// decodeContracts returns the "contracts" object shared by the solc outputs.
+func decodeContracts(raw map[string]json.RawMessage) (map[string]json.RawMessage, bool) {
+	if !isJSONObject(raw["contracts"]) {
+		return nil, false
+	}
+	var contracts map[string]json.RawMessage
+	if err := json.Unmarshal(raw["contracts"], &contracts); err != nil {
+		return nil, false
+	}
+	return contracts, true
+}
+
+// selectContract returns the key of contracts ("file:Name") matching
+// contract, which may be a plain name or a fully qualified one.
+func selectContract(contracts map[string]json.RawMessage, contract string) (string, error) {
+	names := make([]string, 0, len(contracts))
+	for name := range contracts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if contract == "" {
+		if len(names) != 1 {
+			return "", fmt.Errorf("%w: found %d contracts, select one of %s", ErrInvalidArtifact, len(names), strings.Join(names, ", "))
+		}
+		return names[0], nil
+	}
+
+	var matches []string
+	for _, name := range names {
+		if name == contract || name[strings.LastIndex(name, ":")+1:] == contract {
+			matches = append(matches, name)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%w: contract %s not found in %s", ErrInvalidArtifact, contract, strings.Join(names, ", "))
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("%w: contract %s is ambiguous: %s", ErrInvalidArtifact, contract, strings.Join(matches, ", "))
+	}
+}
+
+func unmarshalRaw(raw map[string]json.RawMessage, v interface{}) error {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidArtifact, err)
+	}
+	return nil
+}
+
+func isJSONObject(data json.RawMessage) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] == '{'
+}
+
+func isJSONString(data json.RawMessage) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] == '"'
+}
@@ -0,0 +1,222 @@
+package framework
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// zeroPlaceholder is the 20 bytes a library placeholder is decoded to.
+var zeroPlaceholder = make([]byte, 20)
+
+func joinCode(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func detectFormat(t *testing.T, data []byte) string {
+	t.Helper()
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	for _, decoder := range artifactDecoders {
+		if decoder.Detect(raw) {
+			return decoder.Name()
+		}
+	}
+	return ""
+}
+
+func TestDecodeArtifactFormats(t *testing.T) {
+	const placeholder = "$0123456789abcdef0123456789abcdef01$"
+
+	tests := []struct {
+		file     string
+		contract string
+		format   string
+
+		code         []byte
+		deployedCode []byte
+		linkRefs     LinkReferences
+		deployedRefs LinkReferences
+	}{
+		{
+			file:         "foundry.json",
+			format:       "foundry",
+			code:         joinCode([]byte{0x60, 0x01}, zeroPlaceholder, []byte{0x60, 0x02}),
+			deployedCode: joinCode([]byte{0x60, 0x03}, zeroPlaceholder),
+			linkRefs:     LinkReferences{"src/Math.sol:Math": {2}},
+			deployedRefs: LinkReferences{"src/Math.sol:Math": {2}},
+		},
+		{
+			file:     "hardhat.json",
+			format:   "hardhat",
+			code:     joinCode([]byte{0x60, 0x01}, zeroPlaceholder),
+			linkRefs: LinkReferences{"contracts/Math.sol:Math": {2}},
+		},
+		{
+			file:     "combined.json",
+			contract: "Lib",
+			format:   "solc combined-json",
+			code:     joinCode([]byte{0x60, 0x01}, zeroPlaceholder),
+			linkRefs: LinkReferences{placeholder: {2}},
+		},
+		{
+			file:         "combined.json",
+			contract:     "src/Main.sol:Main",
+			format:       "solc combined-json",
+			code:         []byte{0x60, 0x02},
+			deployedCode: []byte{0x60, 0x03},
+		},
+		{
+			file:         "standard.json",
+			contract:     "src/A.sol:Token",
+			format:       "solc standard-json",
+			code:         []byte{0x60, 0x01},
+			deployedCode: []byte{0x60, 0x02},
+		},
+		{
+			file:     "standard.json",
+			contract: "Vault",
+			format:   "solc standard-json",
+			code:     joinCode([]byte{0x60, 0x04}, zeroPlaceholder),
+			linkRefs: LinkReferences{"src/Math.sol:Math": {2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file+":"+tt.contract, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "artifacts", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format := detectFormat(t, data); format != tt.format {
+				t.Fatalf("detected %q, want %q", format, tt.format)
+			}
+
+			artifact, err := DecodeArtifactContract(data, tt.contract)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if artifact.Abi == nil {
+				t.Error("abi not decoded")
+			}
+			if !bytes.Equal(artifact.Code, tt.code) {
+				t.Errorf("code is %x, want %x", artifact.Code, tt.code)
+			}
+			if !bytes.Equal(artifact.DeployedCode, tt.deployedCode) {
+				t.Errorf("deployed code is %x, want %x", artifact.DeployedCode, tt.deployedCode)
+			}
+			if !reflect.DeepEqual(artifact.LinkReferences, tt.linkRefs) {
+				t.Errorf("link references are %v, want %v", artifact.LinkReferences, tt.linkRefs)
+			}
+			if !reflect.DeepEqual(artifact.DeployedLinkReferences, tt.deployedRefs) {
+				t.Errorf("deployed link references are %v, want %v", artifact.DeployedLinkReferences, tt.deployedRefs)
+			}
+		})
+	}
+}
+
+func TestDecodeArtifactErrors(t *testing.T) {
+	tests := []struct {
+		desc     string
+		data     string
+		contract string
+		err      string
+	}{
+		{
+			desc: "foundry bytecode without 0x",
+			data: `{"abi": [], "bytecode": {"object": "6001"}}`,
+			err:  "not 0x prefixed",
+		},
+		{
+			desc: "foundry empty bytecode",
+			data: `{"abi": [], "bytecode": {"object": "0x"}}`,
+			err:  "empty bytecode",
+		},
+		{
+			desc: "foundry missing abi",
+			data: `{"bytecode": {"object": "0x6001"}}`,
+			err:  "missing abi",
+		},
+		{
+			desc: "hardhat bytecode without 0x",
+			data: `{"_format": "hh-sol-artifact-1", "abi": [], "bytecode": "6001"}`,
+			err:  "not 0x prefixed",
+		},
+		{
+			desc: "hardhat empty bytecode",
+			data: `{"_format": "hh-sol-artifact-1", "abi": [], "bytecode": "0x"}`,
+			err:  "empty bytecode",
+		},
+		{
+			desc: "combined-json bytecode with 0x",
+			data: `{"contracts": {"A.sol:A": {"abi": [], "bin": "0x6001"}}}`,
+			err:  "bytecode",
+		},
+		{
+			desc: "combined-json empty bytecode",
+			data: `{"contracts": {"A.sol:A": {"abi": [], "bin": ""}}}`,
+			err:  "empty bytecode",
+		},
+		{
+			desc: "standard-json empty bytecode",
+			data: `{"contracts": {"A.sol": {"A": {"abi": [], "evm": {"bytecode": {"object": ""}}}}}}`,
+			err:  "empty bytecode",
+		},
+		{
+			desc: "malformed placeholder",
+			data: `{"abi": [], "bytecode": {"object": "0x6001__$01$__"}}`,
+			err:  "malformed library placeholder",
+		},
+		{
+			desc: "unknown format",
+			data: `{"abi": []}`,
+			err:  "unknown artifact format",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := DecodeArtifactContract([]byte(tt.data), tt.contract)
+			if !errors.Is(err, ErrInvalidArtifact) || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestSelectContract(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "artifacts", "standard.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		contract string
+		err      string
+	}{
+		{contract: "", err: "found 3 contracts, select one of src/A.sol:Token, src/B.sol:Token, src/B.sol:Vault"},
+		{contract: "Token", err: "contract Token is ambiguous: src/A.sol:Token, src/B.sol:Token"},
+		{contract: "Missing", err: "contract Missing not found"},
+		{contract: "src/B.sol:Token"},
+		{contract: "Vault"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contract, func(t *testing.T) {
+			_, err := DecodeArtifactContract(data, tt.contract)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidArtifact) || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package framework
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestArtifactLink(t *testing.T) {
+	const name = "src/Math.sol:Math"
+	placeholder := "$" + hex.EncodeToString(crypto.Keccak256([]byte(name)))[:34] + "$"
+	addr := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	tests := []struct {
+		desc      string
+		ref       string
+		libraries map[string]common.Address
+		err       string
+	}{
+		{desc: "qualified reference by qualified name", ref: name, libraries: map[string]common.Address{name: addr}},
+		{desc: "qualified reference by short name", ref: name, libraries: map[string]common.Address{"Math": addr}},
+		{desc: "placeholder by qualified name", ref: placeholder, libraries: map[string]common.Address{name: addr}},
+		{desc: "placeholder by short name", ref: placeholder, libraries: map[string]common.Address{"Math": addr}, err: "fully qualified name"},
+		{desc: "missing library", ref: name, libraries: map[string]common.Address{"Other": addr}, err: "unlinked library " + name},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			artifact := &Artifact{
+				Code:           make([]byte, 4+common.AddressLength),
+				LinkReferences: LinkReferences{tt.ref: {4}},
+			}
+			code, err := artifact.Link(tt.libraries)
+			if tt.err != "" {
+				if !errors.Is(err, ErrInvalidArtifact) || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(code[4:], addr.Bytes()) {
+				t.Fatalf("got code %x, want the library address at offset 4", code)
+			}
+		})
+	}
+}
+
+func TestArtifactLoaderReadQualified(t *testing.T) {
+	output := `{"contracts": {
+		"a/A.sol:C": {"abi": [], "bin": "6001"},
+		"b/B.sol:C": {"abi": [], "bin": "6002"}
+	}}`
+	loader := NewArtifactLoader(fstest.MapFS{"out/combined.json": {Data: []byte(output)}})
+
+	artifact, err := loader.Read("out/combined.json:b/B.sol:C")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(artifact.Code, []byte{0x60, 0x02}) {
+		t.Errorf("got code %x, want the code of b/B.sol:C", artifact.Code)
+	}
+
+	if _, err := loader.Read("out/combined.json:C"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("got error %v, want C to be ambiguous", err)
+	}
+}
@@ -0,0 +1,257 @@
+// Package bindgen generates typed Go bindings for Suapp contracts from their
+// compiled artifacts.
+package bindgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Generate returns the Go source of package pkg binding the contract name,
+// whose ABI was read from the artifact at artifactPath. artifactPath is
+// embedded in the bindings to load the ABI at run time and must be relative
+// to the artifacts directory of the framework.
+func Generate(pkg, name, artifactPath string, contractAbi *abi.ABI) ([]byte, error) {
+	g := &generator{structs: map[string]*tmplStruct{}}
+	data := &tmplData{
+		Package:      pkg,
+		Name:         name,
+		Type:         capitalise(name),
+		ArtifactPath: artifactPath,
+	}
+
+	data.Constructor = g.params(contractAbi.Constructor.Inputs)
+
+	// the methods of the events and the embedded contract field are taken
+	// before the contract methods are named
+	taken := map[string]bool{"Contract": true, "Ref": true}
+	for name := range contractAbi.Events {
+		for _, prefix := range []string{"Parse", "Filter", "Watch"} {
+			taken[prefix+capitalise(name)] = true
+		}
+	}
+
+	names := make([]string, 0, len(contractAbi.Methods))
+	for name := range contractAbi.Methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		method := contractAbi.Methods[name]
+		m := &tmplMethod{
+			Key:     name,
+			GoName:  methodName(name, taken),
+			Sig:     method.Sig,
+			Inputs:  g.params(method.Inputs),
+			Outputs: g.outputs(method.Outputs),
+		}
+		if method.IsConstant() {
+			data.Calls = append(data.Calls, m)
+		} else {
+			data.Transacts = append(data.Transacts, m)
+		}
+	}
+
+	names = names[:0]
+	for name := range contractAbi.Events {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		event := contractAbi.Events[name]
+		e := &tmplEvent{
+			Key:    name,
+			GoName: capitalise(name),
+			Sig:    event.Sig,
+		}
+		for i, input := range event.Inputs {
+			name := capitalise(input.Name)
+			if name == "" || name == "Raw" {
+				name = fmt.Sprintf("Arg%d", i)
+			}
+			e.Fields = append(e.Fields, &tmplField{Name: name, Type: g.eventType(input)})
+		}
+		data.Events = append(data.Events, e)
+	}
+
+	for _, s := range g.structs {
+		data.Structs = append(data.Structs, s)
+	}
+	sort.Slice(data.Structs, func(i, j int) bool { return data.Structs[i].Name < data.Structs[j].Name })
+
+	var buf bytes.Buffer
+	if err := bindingTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format bindings of %s: %w\n%s", name, err, buf.Bytes())
+	}
+	return code, nil
+}
+
+// PackageName returns the default package name for the bindings of a contract.
+func PackageName(contract string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(contract) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	pkg := b.String()
+	if pkg == "" || unicode.IsDigit(rune(pkg[0])) || token.IsKeyword(pkg) {
+		pkg = "contract" + pkg
+	}
+	return pkg
+}
+
+// methodName returns the Go name of a contract method, suffixed with a number
+// if the binding has a method or field of that name already.
+func methodName(name string, taken map[string]bool) string {
+	goName := capitalise(name)
+	for i := 0; taken[goName]; i++ {
+		goName = fmt.Sprintf("%s%d", capitalise(name), i)
+	}
+	taken[goName] = true
+	return goName
+}
+
+// reservedParams are the identifiers the generated functions use besides their
+// parameters: the receiver, locals, builtins and imported packages.
+var reservedParams = map[string]bool{
+	"c": true, "ctx": true, "opts": true, "confidentialInputs": true,
+	"fr": true, "contract": true, "values": true, "out": true, "err": true,
+	"log": true, "logs": true, "ev": true, "events": true, "sink": true, "sub": true,
+	"new": true, "append": true,
+	"context": true, "big": true, "abi": true, "common": true, "types": true, "event": true, "framework": true,
+}
+
+type generator struct {
+	structs map[string]*tmplStruct
+}
+
+func (g *generator) params(args abi.Arguments) []*tmplParam {
+	params := make([]*tmplParam, len(args))
+	for i, arg := range args {
+		name := decapitalise(arg.Name)
+		if name == "" || token.IsKeyword(name) || reservedParams[name] {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		params[i] = &tmplParam{Name: name, Type: g.goType(arg.Type)}
+	}
+	return params
+}
+
+func (g *generator) outputs(args abi.Arguments) []*tmplField {
+	fields := make([]*tmplField, len(args))
+	for i, arg := range args {
+		name := capitalise(arg.Name)
+		if name == "" {
+			name = fmt.Sprintf("Arg%d", i)
+		}
+		fields[i] = &tmplField{Name: name, Type: g.goType(arg.Type)}
+	}
+	return fields
+}
+
+// eventType returns the Go type of an event input. Indexed inputs of dynamic
+// types are only logged as the hash of their value.
+func (g *generator) eventType(input abi.Argument) string {
+	if input.Indexed {
+		switch input.Type.T {
+		case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+			return "common.Hash"
+		}
+	}
+	return g.goType(input.Type)
+}
+
+// goType returns the Go type the abi package decodes typ into, declaring the
+// structs of tuples on the way.
+func (g *generator) goType(typ abi.Type) string {
+	switch typ.T {
+	case abi.IntTy, abi.UintTy:
+		prefix := "int"
+		if typ.T == abi.UintTy {
+			prefix = "uint"
+		}
+		switch typ.Size {
+		case 8, 16, 32, 64:
+			return fmt.Sprintf("%s%d", prefix, typ.Size)
+		}
+		return "*big.Int"
+	case abi.BoolTy:
+		return "bool"
+	case abi.StringTy:
+		return "string"
+	case abi.AddressTy:
+		return "common.Address"
+	case abi.BytesTy:
+		return "[]byte"
+	case abi.FixedBytesTy:
+		return fmt.Sprintf("[%d]byte", typ.Size)
+	case abi.HashTy:
+		return "common.Hash"
+	case abi.FunctionTy:
+		return "[24]byte"
+	case abi.SliceTy:
+		return "[]" + g.goType(*typ.Elem)
+	case abi.ArrayTy:
+		return fmt.Sprintf("[%d]%s", typ.Size, g.goType(*typ.Elem))
+	case abi.TupleTy:
+		return g.tuple(typ)
+	}
+	return "interface{}"
+}
+
+// tuple declares the struct mirroring a Solidity struct. Structs of the Suave
+// library are named without the library prefix, e.g. BuildBlockArgs.
+func (g *generator) tuple(typ abi.Type) string {
+	raw := typ.TupleRawName
+	if i := strings.Index(raw, "["); i >= 0 {
+		raw = raw[:i]
+	}
+	name := raw
+	if strings.HasPrefix(raw, "Suave") && len(raw) > len("Suave") {
+		name = raw[len("Suave"):]
+		raw = "Suave." + name
+	}
+	if name == "" {
+		name = fmt.Sprintf("Tuple%x", crypto.Keccak256([]byte(typ.String()))[:4])
+	}
+	name = capitalise(name)
+	if _, ok := g.structs[name]; ok {
+		return name
+	}
+
+	s := &tmplStruct{Name: name, Raw: raw}
+	g.structs[name] = s
+	for i, elem := range typ.TupleElems {
+		s.Fields = append(s.Fields, &tmplField{Name: capitalise(typ.TupleRawNames[i]), Type: g.goType(*elem)})
+	}
+	return name
+}
+
+func capitalise(s string) string {
+	s = abi.ToCamelCase(s)
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func decapitalise(s string) string {
+	s = abi.ToCamelCase(s)
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
@@ -0,0 +1,78 @@
+package bindgen
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestGenerate(t *testing.T) {
+	for _, name := range []string{"Events", "Collisions"} {
+		t.Run(name, func(t *testing.T) {
+			base := strings.ToLower(name)
+			data, err := os.ReadFile(filepath.Join("testdata", base+".abi.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			contractAbi, err := abi.JSON(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			code, err := Generate(base, name, base+".sol/"+name+".json", &contractAbi)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", base+".go.golden")
+			if *update {
+				if err := os.WriteFile(golden, code, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(code, want) {
+				t.Errorf("bindings differ from %s, run with -update to review the changes:\n%s", golden, code)
+			}
+
+			vet(t, base, code)
+		})
+	}
+}
+
+// vet checks that the bindings compile against the framework. The package is
+// written under testdata, which is part of the module but skipped by ./...
+func vet(t *testing.T, pkg string, code []byte) {
+	if testing.Short() {
+		t.Skip("vetting the bindings needs the go command")
+	}
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	dir, err := os.MkdirTemp("testdata", "vet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	if err := os.WriteFile(filepath.Join(dir, pkg+".go"), code, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := exec.Command(goCmd, "vet", "./"+filepath.ToSlash(dir)).CombinedOutput()
+	if err != nil {
+		t.Fatalf("bindings do not vet: %v\n%s", err, out)
+	}
+}
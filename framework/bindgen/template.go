@@ -0,0 +1,221 @@
+package bindgen
+
+import "text/template"
+
+type tmplData struct {
+	Package      string
+	Name         string
+	Type         string
+	ArtifactPath string
+	Constructor  []*tmplParam
+	Calls        []*tmplMethod
+	Transacts    []*tmplMethod
+	Events       []*tmplEvent
+	Structs      []*tmplStruct
+}
+
+type tmplMethod struct {
+	Key     string
+	GoName  string
+	Sig     string
+	Inputs  []*tmplParam
+	Outputs []*tmplField
+}
+
+type tmplEvent struct {
+	Key    string
+	GoName string
+	Sig    string
+	Fields []*tmplField
+}
+
+type tmplStruct struct {
+	Name   string
+	Raw    string
+	Fields []*tmplField
+}
+
+type tmplParam struct {
+	Name string
+	Type string
+}
+
+type tmplField struct {
+	Name string
+	Type string
+}
+
+var bindingTemplate = template.Must(template.New("binding").Parse(bindingSource))
+
+const bindingSource = `// Code generated by suapp-bindgen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/flashbots/suapp-examples/framework"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = big.NewInt
+	_ = abi.ConvertType
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+)
+
+// ArtifactPath is the artifact the bindings were generated from, relative to
+// the artifacts directory.
+const ArtifactPath = "{{.ArtifactPath}}"
+{{range .Structs}}
+// {{.Name}} mirrors the Solidity struct {{or .Raw .Name}}.
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}}
+{{- end}}
+}
+{{end}}
+// {{.Type}} is a typed binding of the {{.Name}} contract.
+type {{.Type}} struct {
+	*framework.Contract
+}
+
+// New{{.Type}} binds the {{.Name}} contract deployed at addr.
+func New{{.Type}}(fr *framework.Framework, addr common.Address) (*{{.Type}}, error) {
+	artifact, err := fr.ReadArtifact(ArtifactPath)
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Type}}{Contract: fr.ContractAt(addr, artifact.Abi)}, nil
+}
+
+// Deploy{{.Type}} deploys the {{.Name}} contract from the funded account.
+func Deploy{{.Type}}(ctx context.Context, fr *framework.Framework{{range .Constructor}}, {{.Name}} {{.Type}}{{end}}, opts ...framework.DeployOption) (*{{.Type}}, error) {
+{{- if .Constructor}}
+	opts = append([]framework.DeployOption{framework.WithConstructorArgs({{range $i, $p := .Constructor}}{{if $i}}, {{end}}{{$p.Name}}{{end}})}, opts...)
+{{- end}}
+	contract, err := fr.DeployContractContext(ctx, ArtifactPath, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Type}}{Contract: contract}, nil
+}
+
+// Ref returns a binding sending transactions from acct.
+func (c *{{.Type}}) Ref(acct *framework.PrivKey) *{{.Type}} {
+	return &{{.Type}}{Contract: c.Contract.Ref(acct)}
+}
+{{range .Calls}}
+{{- if gt (len .Outputs) 1}}
+// {{$.Type}}{{.GoName}}Output holds the outputs of {{.GoName}}.
+type {{$.Type}}{{.GoName}}Output struct {
+{{- range .Outputs}}
+	{{.Name}} {{.Type}}
+{{- end}}
+}
+{{end}}
+// {{.GoName}} calls {{.Sig}}.
+func (c *{{$.Type}}) {{.GoName}}(ctx context.Context{{range .Inputs}}, {{.Name}} {{.Type}}{{end}}, opts ...framework.CallOption) ({{if eq (len .Outputs) 1}}{{(index .Outputs 0).Type}}, {{else if .Outputs}}*{{$.Type}}{{.GoName}}Output, {{end}}error) {
+	{{if .Outputs}}values{{else}}_{{end}}, err := c.Contract.Read(ctx, "{{.Key}}", []interface{}{ {{- range $i, $p := .Inputs}}{{if $i}}, {{end}}{{$p.Name}}{{end -}} }, opts...)
+{{- if eq (len .Outputs) 1}}
+	if err != nil {
+		return *new({{(index .Outputs 0).Type}}), err
+	}
+	return *abi.ConvertType(values[0], new({{(index .Outputs 0).Type}})).(*{{(index .Outputs 0).Type}}), nil
+{{- else if .Outputs}}
+	if err != nil {
+		return nil, err
+	}
+	out := new({{$.Type}}{{.GoName}}Output)
+{{- range $i, $o := .Outputs}}
+	out.{{$o.Name}} = *abi.ConvertType(values[{{$i}}], new({{$o.Type}})).(*{{$o.Type}})
+{{- end}}
+	return out, nil
+{{- else}}
+	return err
+{{- end}}
+}
+{{end}}
+{{- range .Transacts}}
+// {{.GoName}} sends {{.Sig}} as a confidential request, with
+// confidentialInputs only visible to the kettle.
+func (c *{{$.Type}}) {{.GoName}}(ctx context.Context{{range .Inputs}}, {{.Name}} {{.Type}}{{end}}, confidentialInputs []byte, opts ...framework.TxOption) (*framework.Result, error) {
+	return c.Contract.Send(ctx, "{{.Key}}", []interface{}{ {{- range $i, $p := .Inputs}}{{if $i}}, {{end}}{{$p.Name}}{{end -}} }, confidentialInputs, opts...)
+}
+{{end}}
+{{- range .Events}}
+// {{$.Type}}{{.GoName}} is a {{.Sig}} event of the contract.
+type {{$.Type}}{{.GoName}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}}
+{{- end}}
+	Raw types.Log
+}
+
+// Parse{{.GoName}} decodes a {{.GoName}} log.
+func (c *{{$.Type}}) Parse{{.GoName}}(log types.Log) (*{{$.Type}}{{.GoName}}, error) {
+	ev := new({{$.Type}}{{.GoName}})
+	if err := c.Contract.UnpackLog(ev, "{{.Key}}", log); err != nil {
+		return nil, err
+	}
+	ev.Raw = log
+	return ev, nil
+}
+
+// Filter{{.GoName}} returns the {{.GoName}} events between fromBlock and toBlock.
+// A nil toBlock means the latest block.
+func (c *{{$.Type}}) Filter{{.GoName}}(ctx context.Context, fromBlock, toBlock *big.Int) ([]*{{$.Type}}{{.GoName}}, error) {
+	logs, err := c.Contract.FilterLogs(ctx, "{{.Key}}", fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]*{{$.Type}}{{.GoName}}, 0, len(logs))
+	for _, log := range logs {
+		ev, err := c.Parse{{.GoName}}(log)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	return events, nil
+}
+
+// Watch{{.GoName}} streams new {{.GoName}} events to sink.
+func (c *{{$.Type}}) Watch{{.GoName}}(ctx context.Context, sink chan<- *{{$.Type}}{{.GoName}}) (event.Subscription, error) {
+	logs := make(chan types.Log)
+	sub, err := c.Contract.WatchLogs(ctx, "{{.Key}}", logs)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				ev, err := c.Parse{{.GoName}}(log)
+				if err != nil {
+					return err
+				}
+				select {
+				case sink <- ev:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+{{end}}`
@@ -0,0 +1,138 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type callOptions struct {
+	from      common.Address
+	block     rpc.BlockNumberOrHash
+	overrides map[common.Address]gethclient.OverrideAccount
+}
+
+// CallOption customizes a read-only call.
+type CallOption func(*callOptions)
+
+func newCallOptions(opts []CallOption) *callOptions {
+	o := &callOptions{block: rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// AtBlock executes the call against the state of block number. A nil number
+// means the latest block, as in ethclient.
+func AtBlock(number *big.Int) CallOption {
+	return func(o *callOptions) {
+		if number == nil {
+			o.block = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+			return
+		}
+		o.block = rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(number.Int64()))
+	}
+}
+
+// AtBlockHash executes the call against the state of the block with the given hash.
+func AtBlockHash(hash common.Hash) CallOption {
+	return func(o *callOptions) {
+		o.block = rpc.BlockNumberOrHashWithHash(hash, false)
+	}
+}
+
+// AtPending executes the call against the pending state.
+func AtPending() CallOption {
+	return func(o *callOptions) {
+		o.block = rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+	}
+}
+
+// WithFrom sets the sender of the call.
+func WithFrom(from common.Address) CallOption {
+	return func(o *callOptions) {
+		o.from = from
+	}
+}
+
+// WithStateOverride replaces the state of addr during the call.
+func WithStateOverride(addr common.Address, account gethclient.OverrideAccount) CallOption {
+	return func(o *callOptions) {
+		if o.overrides == nil {
+			o.overrides = map[common.Address]gethclient.OverrideAccount{}
+		}
+		o.overrides[addr] = account
+	}
+}
+
+// Read executes a read-only call of methodName with args and returns the
+// unpacked outputs.
+func (c *Contract) Read(ctx context.Context, methodName string, args []interface{}, opts ...CallOption) ([]interface{}, error) {
+	output, err := c.rawCall(ctx, methodName, args, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := c.abi.Methods[methodName].Outputs.Unpack(output)
+	if err != nil {
+		return nil, &ABIError{Method: methodName, Err: err}
+	}
+	return results, nil
+}
+
+// ReadInto is like Read but copies the outputs into out, a pointer to a value
+// of the single output type or to a struct with a field per output.
+func (c *Contract) ReadInto(ctx context.Context, out interface{}, methodName string, args []interface{}, opts ...CallOption) error {
+	output, err := c.rawCall(ctx, methodName, args, opts)
+	if err != nil {
+		return err
+	}
+
+	outputs := c.abi.Methods[methodName].Outputs
+	values, err := outputs.Unpack(output)
+	if err != nil {
+		return &ABIError{Method: methodName, Err: err}
+	}
+	if err := outputs.Copy(out, values); err != nil {
+		return &ABIError{Method: methodName, Err: err}
+	}
+	return nil
+}
+
+func (c *Contract) rawCall(ctx context.Context, methodName string, args []interface{}, opts []CallOption) ([]byte, error) {
+	if _, ok := c.abi.Methods[methodName]; !ok {
+		return nil, &ABIError{Method: methodName, Err: errors.New("method not found")}
+	}
+
+	input, err := c.abi.Pack(methodName, args...)
+	if err != nil {
+		return nil, &ABIError{Method: methodName, Err: err}
+	}
+
+	o := newCallOptions(opts)
+	callArgs := map[string]interface{}{
+		"from": o.from,
+		"to":   c.addr,
+		"data": hexutil.Bytes(input),
+	}
+
+	params := []interface{}{callArgs, o.block}
+	if len(o.overrides) != 0 {
+		params = append(params, o.overrides)
+	}
+
+	var output hexutil.Bytes
+	err = c.fr.config.Retry.Do(ctx, func() error {
+		return c.fr.rpc.CallContext(ctx, &output, "eth_call", params...)
+	})
+	if err != nil {
+		return nil, c.fr.callError("eth_call", err)
+	}
+	return output, nil
+}
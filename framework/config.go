@@ -0,0 +1,391 @@
+package framework
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables read by LoadConfig.
+const (
+	EnvConfigFile     = "SUAPP_CONFIG"
+	EnvNetwork        = "SUAPP_NETWORK"
+	EnvKettleRPC      = "SUAPP_KETTLE_RPC"
+	EnvKettleWS       = "SUAPP_KETTLE_WS"
+	EnvKettleAddr     = "SUAPP_KETTLE_ADDR"
+	EnvFundedAccount  = "SUAPP_FUNDED_ACCOUNT"
+	EnvReceiptTimeout = "SUAPP_RECEIPT_TIMEOUT"
+	EnvArtifactsDir   = "SUAPP_ARTIFACTS_DIR"
+	EnvCreate2Factory = "SUAPP_CREATE2_FACTORY"
+	EnvDeploySalt     = "SUAPP_DEPLOY_SALT"
+	EnvManifest       = "SUAPP_MANIFEST"
+	EnvConfirmations  = "SUAPP_CONFIRMATIONS"
+	EnvRetryAttempts  = "SUAPP_RETRY_ATTEMPTS"
+	EnvRetryBackoff   = "SUAPP_RETRY_BACKOFF"
+)
+
+var errInvalidConfig = errors.New("invalid config")
+
+type Config struct {
+	// Network is the selected network profile. Selecting a profile also
+	// sets KettleRPC and KettleAddr.
+	Network *Network
+
+	KettleRPC string
+	// KettleWS is the websocket endpoint logs are subscribed through. If
+	// empty, logs are read from KettleRPC. Setting KettleRPC alone clears it.
+	KettleWS      string
+	KettleAddr    common.Address
+	FundedAccount *PrivKey
+
+	// ReceiptTimeout bounds receipt waits when the context has no deadline.
+	ReceiptTimeout time.Duration
+
+	// Retry is the policy of the calls, sends and receipt waits failing
+	// with a transient error.
+	Retry RetryPolicy
+
+	// ArtifactsDir is the directory with the compiled contracts. If empty,
+	// DefaultArtifactsDir is used. ArtifactsFS takes precedence when set,
+	// e.g. to load artifacts embedded in the binary.
+	ArtifactsDir string
+	ArtifactsFS  fs.FS
+
+	// Create2Factory deploys the contracts deployed with a salt.
+	Create2Factory common.Address
+
+	// DeploySalt, if set, deploys the contracts deployed without WithSalt
+	// through Create2Factory with Create2Salt(DeploySalt), so that they keep
+	// their address across runs. The default factory is deployed on first
+	// use, which needs a node accepting transactions without replay
+	// protection.
+	DeploySalt string
+
+	// Manifest is the file recording the deployed contracts. If empty,
+	// DefaultManifestPath is used.
+	Manifest string
+
+	// Confirmations is the number of blocks built on top of a log before
+	// subscribers deliver it.
+	Confirmations uint64
+
+	// Nonces hands out the nonces of the transactions sent by the framework.
+	// If nil, DefaultNonceManager is used.
+	Nonces *NonceManager
+
+	// Connections shares the RPC clients of several frameworks. If nil, each
+	// framework keeps its own and closes them with Close.
+	Connections *Connections
+
+	// networks are the profiles declared by the loaded config files, which
+	// take precedence over the registered ones.
+	networks map[string]*Network
+}
+
+func DefaultConfig() *Config {
+	network, err := LookupNetwork(DefaultNetwork)
+	if err != nil {
+		panic(err)
+	}
+
+	return &Config{
+		Network:    network,
+		KettleRPC:  network.KettleRPC,
+		KettleWS:   network.KettleWS,
+		KettleAddr: network.KettleAddr,
+
+		// This account is funded in both devnev networks
+		// address: 0xBE69d72ca5f88aCba033a063dF5DBe43a4148De0
+		FundedAccount: NewPrivKeyFromHex("91ab9a7e53c220e6210460b65a7a3bb2ca181412a8a7b43ff336b3df1737ce12"),
+
+		ReceiptTimeout: 10 * time.Second,
+		Retry:          DefaultRetryPolicy(),
+
+		Create2Factory: DefaultCreate2Factory,
+	}
+}
+
+// LoadConfig returns the default config overridden by the file at path and
+// then by the SUAPP_* environment variables. If path is empty, the file named
+// by SUAPP_CONFIG is used, if any.
+func LoadConfig(path string) (*Config, error) {
+	cfg := DefaultConfig()
+
+	if path == "" {
+		path = os.Getenv(EnvConfigFile)
+	}
+	if path != "" {
+		if err := cfg.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.LoadEnv(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// UseNetwork selects the network profile and points the kettle settings at it.
+func (c *Config) UseNetwork(n *Network) {
+	c.Network = n
+	if n.KettleRPC != "" {
+		c.KettleRPC = n.KettleRPC
+		c.KettleWS = n.KettleWS
+	}
+	if n.KettleAddr != (common.Address{}) {
+		c.KettleAddr = n.KettleAddr
+	}
+}
+
+// configFile is the TOML/YAML representation of Config. Empty fields
+// leave the current value untouched.
+type configFile struct {
+	Network        string  `toml:"network" yaml:"network"`
+	KettleRPC      string  `toml:"kettle_rpc" yaml:"kettle_rpc"`
+	KettleWS       string  `toml:"kettle_ws" yaml:"kettle_ws"`
+	KettleAddr     string  `toml:"kettle_addr" yaml:"kettle_addr"`
+	FundedAccount  string  `toml:"funded_account" yaml:"funded_account"`
+	ReceiptTimeout string  `toml:"receipt_timeout" yaml:"receipt_timeout"`
+	ArtifactsDir   string  `toml:"artifacts_dir" yaml:"artifacts_dir"`
+	Create2Factory string  `toml:"create2_factory" yaml:"create2_factory"`
+	DeploySalt     string  `toml:"deploy_salt" yaml:"deploy_salt"`
+	Manifest       string  `toml:"manifest" yaml:"manifest"`
+	Confirmations  *uint64 `toml:"confirmations" yaml:"confirmations"`
+	RetryAttempts  *uint64 `toml:"retry_attempts" yaml:"retry_attempts"`
+	RetryBackoff   string  `toml:"retry_backoff" yaml:"retry_backoff"`
+
+	// Networks declares additional network profiles.
+	Networks map[string]networkFile `toml:"networks" yaml:"networks"`
+}
+
+// LoadFile overrides the config with the values of a TOML or YAML file,
+// selected by the file extension.
+func (c *Config) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var file configFile
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".toml":
+		err = toml.Unmarshal(data, &file)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &file)
+	default:
+		return fmt.Errorf("%w: unsupported config file extension %q", errInvalidConfig, ext)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", errInvalidConfig, path, err)
+	}
+
+	var confirmations, retryAttempts string
+	if file.Confirmations != nil {
+		confirmations = strconv.FormatUint(*file.Confirmations, 10)
+	}
+	if file.RetryAttempts != nil {
+		retryAttempts = strconv.FormatUint(*file.RetryAttempts, 10)
+	}
+
+	for name, n := range file.Networks {
+		network, err := n.toNetwork(name)
+		if err != nil {
+			return err
+		}
+		if c.networks == nil {
+			c.networks = map[string]*Network{}
+		}
+		c.networks[name] = network
+	}
+
+	return c.apply(map[string]string{
+		"network":         file.Network,
+		"kettle_rpc":      file.KettleRPC,
+		"kettle_ws":       file.KettleWS,
+		"kettle_addr":     file.KettleAddr,
+		"funded_account":  file.FundedAccount,
+		"receipt_timeout": file.ReceiptTimeout,
+		"artifacts_dir":   file.ArtifactsDir,
+		"create2_factory": file.Create2Factory,
+		"deploy_salt":     file.DeploySalt,
+		"manifest":        file.Manifest,
+		"confirmations":   confirmations,
+		"retry_attempts":  retryAttempts,
+		"retry_backoff":   file.RetryBackoff,
+	})
+}
+
+// LoadEnv overrides the config with the SUAPP_* environment variables that are set.
+func (c *Config) LoadEnv() error {
+	return c.apply(map[string]string{
+		"network":         os.Getenv(EnvNetwork),
+		"kettle_rpc":      os.Getenv(EnvKettleRPC),
+		"kettle_ws":       os.Getenv(EnvKettleWS),
+		"kettle_addr":     os.Getenv(EnvKettleAddr),
+		"funded_account":  os.Getenv(EnvFundedAccount),
+		"receipt_timeout": os.Getenv(EnvReceiptTimeout),
+		"artifacts_dir":   os.Getenv(EnvArtifactsDir),
+		"create2_factory": os.Getenv(EnvCreate2Factory),
+		"deploy_salt":     os.Getenv(EnvDeploySalt),
+		"manifest":        os.Getenv(EnvManifest),
+		"confirmations":   os.Getenv(EnvConfirmations),
+		"retry_attempts":  os.Getenv(EnvRetryAttempts),
+		"retry_backoff":   os.Getenv(EnvRetryBackoff),
+	})
+}
+
+// RegisterFlags registers command line flags on fs that override the config
+// when fs is parsed. Flags apply in the order of configFields whatever their
+// order on the command line.
+func (c *Config) RegisterFlags(fs *flag.FlagSet) {
+	// the flags parsed so far are applied again after each one, as fs has no
+	// hook once parsing is done
+	values := map[string]string{}
+	for _, name := range configFields {
+		name := name
+		fs.Func(strings.ReplaceAll(name, "_", "-"), configUsage[name], func(value string) error {
+			values[name] = value
+			return c.apply(values)
+		})
+	}
+}
+
+// configFields lists the settable fields in the order they are applied,
+// so a network profile never overrides an explicit kettle setting.
+var configFields = []string{"network", "kettle_rpc", "kettle_ws", "kettle_addr", "funded_account", "receipt_timeout", "artifacts_dir", "create2_factory", "deploy_salt", "manifest", "confirmations", "retry_attempts", "retry_backoff"}
+
+var configUsage = map[string]string{
+	"network":         "name of the network profile",
+	"kettle_rpc":      "RPC endpoint of the kettle",
+	"kettle_ws":       "websocket endpoint of the kettle logs are subscribed through",
+	"kettle_addr":     "address of the kettle executing confidential requests",
+	"funded_account":  "hex encoded private key of the funded account",
+	"receipt_timeout": "maximum time to wait for a transaction receipt",
+	"artifacts_dir":   "directory with the compiled contract artifacts",
+	"create2_factory": "address of the CREATE2 factory used for salted deployments",
+	"deploy_salt":     "label of the salt deploying the contracts through the CREATE2 factory",
+	"manifest":        "file recording the deployed contracts",
+	"confirmations":   "number of blocks on top of a log before it is delivered",
+	"retry_attempts":  "number of attempts of requests failing with a transient error",
+	"retry_backoff":   "delay before the first retry of a request, doubled after each attempt",
+}
+
+func (c *Config) apply(values map[string]string) error {
+	for _, name := range configFields {
+		value := values[name]
+		if value == "" {
+			continue
+		}
+		if err := c.set(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Config) set(name, value string) error {
+	switch name {
+	case "network":
+		network, err := lookupNetwork(value, c.networks)
+		if err != nil {
+			return err
+		}
+		c.UseNetwork(network)
+	case "kettle_rpc":
+		// the websocket endpoint of the profile belongs to another kettle
+		c.KettleRPC = value
+		c.KettleWS = ""
+	case "kettle_ws":
+		c.KettleWS = value
+	case "kettle_addr":
+		if !common.IsHexAddress(value) {
+			return fmt.Errorf("%w: kettle_addr %q is not an address", errInvalidConfig, value)
+		}
+		c.KettleAddr = common.HexToAddress(value)
+	case "funded_account":
+		key, err := ParsePrivKey(strings.TrimPrefix(value, "0x"))
+		if err != nil {
+			return fmt.Errorf("%w: funded_account: %w", errInvalidConfig, err)
+		}
+		c.FundedAccount = key
+	case "receipt_timeout":
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%w: receipt_timeout: %w", errInvalidConfig, err)
+		}
+		c.ReceiptTimeout = timeout
+	case "artifacts_dir":
+		c.ArtifactsDir = value
+	case "create2_factory":
+		if !common.IsHexAddress(value) {
+			return fmt.Errorf("%w: create2_factory %q is not an address", errInvalidConfig, value)
+		}
+		c.Create2Factory = common.HexToAddress(value)
+	case "deploy_salt":
+		c.DeploySalt = value
+	case "manifest":
+		c.Manifest = value
+	case "confirmations":
+		confirmations, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: confirmations: %w", errInvalidConfig, err)
+		}
+		c.Confirmations = confirmations
+	case "retry_attempts":
+		attempts, err := strconv.ParseUint(value, 10, 31)
+		if err != nil {
+			return fmt.Errorf("%w: retry_attempts: %w", errInvalidConfig, err)
+		}
+		c.Retry.MaxAttempts = int(attempts)
+	case "retry_backoff":
+		backoff, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%w: retry_backoff: %w", errInvalidConfig, err)
+		}
+		c.Retry.MinBackoff = backoff
+	default:
+		return fmt.Errorf("%w: unknown field %q", errInvalidConfig, name)
+	}
+	return nil
+}
+
+func (c *Config) artifactLoader() *ArtifactLoader {
+	if c.ArtifactsFS != nil {
+		return NewArtifactLoader(c.ArtifactsFS)
+	}
+	if c.ArtifactsDir != "" {
+		return NewArtifactLoaderDir(c.ArtifactsDir)
+	}
+	return NewArtifactLoaderDir(DefaultArtifactsDir())
+}
+
+func (c *Config) manifestPath() string {
+	if c.Manifest != "" {
+		return c.Manifest
+	}
+	return DefaultManifestPath()
+}
+
+// Validate reports whether the config has everything needed to build a Framework.
+func (c *Config) Validate() error {
+	if c.KettleRPC == "" {
+		return fmt.Errorf("%w: missing kettle rpc", errInvalidConfig)
+	}
+	if c.Network == nil {
+		return fmt.Errorf("%w: missing network", errInvalidConfig)
+	}
+	if c.FundedAccount == nil {
+		return fmt.Errorf("%w: missing funded account", errInvalidConfig)
+	}
+	return nil
+}
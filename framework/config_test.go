@@ -0,0 +1,52 @@
+package framework
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegisterFlagsOrder(t *testing.T) {
+	for _, args := range [][]string{
+		{"-kettle-rpc", "http://custom:1", "-network", "devenv"},
+		{"-network", "devenv", "-kettle-rpc", "http://custom:1"},
+	} {
+		cfg := DefaultConfig()
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		cfg.RegisterFlags(fs)
+		if err := fs.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+
+		if cfg.Network.Name != "devenv" {
+			t.Errorf("%v: network is %s, want devenv", args, cfg.Network.Name)
+		}
+		if cfg.KettleRPC != "http://custom:1" {
+			t.Errorf("%v: kettle rpc is %s, want http://custom:1", args, cfg.KettleRPC)
+		}
+	}
+}
+
+func TestLoadFileNetworks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "suapp.toml")
+	data := `network = "filenet"
+
+[networks.filenet]
+kettle_rpc = "http://filenet:8545"
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	if err := cfg.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Network.Name != "filenet" || cfg.KettleRPC != "http://filenet:8545" {
+		t.Errorf("network is %s at %s, want filenet at http://filenet:8545", cfg.Network.Name, cfg.KettleRPC)
+	}
+	if _, err := LookupNetwork("filenet"); err == nil {
+		t.Error("network of the config file is registered globally")
+	}
+}
@@ -0,0 +1,121 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var errConnectionsClosed = errors.New("connections closed")
+
+// Connections keeps one long-lived RPC client per endpoint so that the
+// callers of an endpoint share its connections.
+type Connections struct {
+	lock    sync.Mutex
+	clients map[string]*rpc.Client
+	closed  bool
+}
+
+func NewConnections() *Connections {
+	return &Connections{clients: map[string]*rpc.Client{}}
+}
+
+// Client returns the client of url, dialed on first use.
+func (c *Connections) Client(ctx context.Context, url string) (*rpc.Client, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return nil, wrapRPCError("dial", errConnectionsClosed)
+	}
+	if client, ok := c.clients[url]; ok {
+		return client, nil
+	}
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, wrapRPCError("dial", err)
+	}
+	c.clients[url] = client
+	return client, nil
+}
+
+// Eth returns an ethclient over the client of url.
+func (c *Connections) Eth(ctx context.Context, url string) (*ethclient.Client, error) {
+	client, err := c.Client(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return ethclient.NewClient(client), nil
+}
+
+// Health is the result of a health check of an endpoint.
+type Health struct {
+	URL string
+	// Block is the latest block reported by the endpoint.
+	Block   uint64
+	Latency time.Duration
+	// Err is nil if the endpoint answered.
+	Err error
+}
+
+// Check asks the endpoint url for its latest block.
+func (c *Connections) Check(ctx context.Context, url string) Health {
+	health := Health{URL: url}
+
+	client, err := c.Client(ctx, url)
+	if err != nil {
+		health.Err = err
+		return health
+	}
+
+	start := time.Now()
+	var block hexutil.Uint64
+	if err := client.CallContext(ctx, &block, "eth_blockNumber"); err != nil {
+		health.Err = wrapRPCError("eth_blockNumber", err)
+		return health
+	}
+	health.Block = uint64(block)
+	health.Latency = time.Since(start)
+	return health
+}
+
+// Health checks every endpoint dialed so far, sorted by URL.
+func (c *Connections) Health(ctx context.Context) []Health {
+	c.lock.Lock()
+	urls := make([]string, 0, len(c.clients))
+	for url := range c.clients {
+		urls = append(urls, url)
+	}
+	c.lock.Unlock()
+	sort.Strings(urls)
+
+	health := make([]Health, len(urls))
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			health[i] = c.Check(ctx, url)
+		}(i, url)
+	}
+	wg.Wait()
+	return health
+}
+
+// Close closes the clients. Client fails afterwards.
+func (c *Connections) Close() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for url, client := range c.clients {
+		client.Close()
+		delete(c.clients, url)
+	}
+	c.closed = true
+}
@@ -0,0 +1,176 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// DefaultCreate2Factory is the deterministic deployment proxy, deployed at the
+// same address on every chain accepting its pre-EIP-155 deployment transaction.
+// It takes salt ++ init code as calldata and deploys with CREATE2.
+var DefaultCreate2Factory = common.HexToAddress("0x4e59b44847b379578588920cA78FbF26c0B4956C")
+
+var (
+	// create2FactoryDeployer is the keyless account that deploys DefaultCreate2Factory.
+	create2FactoryDeployer = common.HexToAddress("0x3fab184622dc19b6109349b94811493bf2a45362")
+	create2FactoryDeployTx = hexutil.MustDecode("0xf8a58085174876e800830186a08080b853604580600e600039806000f350fe7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe03601600081602082378035828234f58015156039578182fd5b8082525050506014600cf31ba02222222222222222222222222222222222222222222222222222222222222222a02222222222222222222222222222222222222222222222222222222222222222")
+
+	errMissingCreate2Factory = errors.New("create2 factory is not deployed")
+	errCreate2Deploy         = errors.New("create2 deployment did not create a contract")
+)
+
+// Create2Salt derives a salt from a human readable label.
+func Create2Salt(label string) common.Hash {
+	return crypto.Keccak256Hash([]byte(label))
+}
+
+// WithSalt deploys through the CREATE2 factory of the config with salt, so the
+// contract address only depends on the factory, the salt and the init code.
+func WithSalt(salt common.Hash) DeployOption {
+	return func(o *deployOptions) {
+		o.salt = &salt
+	}
+}
+
+// PredictAddress returns the address a deployment of artifact with the given
+// options, which must include WithSalt unless the config has a DeploySalt,
+// ends up at.
+func (f *Framework) PredictAddress(artifact *Artifact, opts ...DeployOption) (common.Address, error) {
+	o := f.deployOptions(opts)
+	if o.salt == nil {
+		return common.Address{}, fmt.Errorf("address prediction requires a salt")
+	}
+	initCode, err := o.initCode(artifact)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.CreateAddress2(f.config.Create2Factory, *o.salt, crypto.Keccak256(initCode)), nil
+}
+
+// EnsureCreate2Factory deploys DefaultCreate2Factory if the config uses it
+// and it has no code yet. Other factories must be deployed beforehand. The
+// deployment transaction of the default factory is not replay protected, so
+// nodes only accept it when run with --rpc.allow-unprotected-txs.
+func (f *Framework) EnsureCreate2Factory(ctx context.Context) error {
+	code, err := f.eth.CodeAt(ctx, f.config.Create2Factory, nil)
+	if err != nil {
+		return wrapRPCError("eth_getCode", err)
+	}
+	if len(code) != 0 {
+		return nil
+	}
+	if f.config.Create2Factory != DefaultCreate2Factory {
+		return fmt.Errorf("%w at %s", errMissingCreate2Factory, f.config.Create2Factory.Hex())
+	}
+
+	txn := new(types.Transaction)
+	if err := txn.UnmarshalBinary(create2FactoryDeployTx); err != nil {
+		return err
+	}
+	// the gas price is part of the signed transaction and cannot be raised
+	var gasPrice *big.Int
+	err = f.config.Retry.Do(ctx, func() (err error) {
+		gasPrice, err = f.eth.SuggestGasPrice(ctx)
+		return err
+	})
+	if err != nil {
+		return wrapRPCError("eth_gasPrice", err)
+	}
+	if txn.GasPrice().Cmp(gasPrice) < 0 {
+		return fmt.Errorf("%w at %s: its deployment transaction pays a gas price of %s, below the %s of the node",
+			errMissingCreate2Factory, DefaultCreate2Factory.Hex(), txn.GasPrice(), gasPrice)
+	}
+
+	// the node checks the replay protection before the balance, so the keyless
+	// deployer is only funded once the node accepts the transaction otherwise
+	hash, err := f.sendRawTransaction(ctx, txn)
+	if err != nil && strings.Contains(err.Error(), "insufficient funds") {
+		if err := f.fundCreate2FactoryDeployer(ctx, txn.Cost()); err != nil {
+			return err
+		}
+		hash, err = f.sendRawTransaction(ctx, txn)
+	}
+	if err != nil {
+		if strings.Contains(err.Error(), "replay-protected") {
+			return fmt.Errorf("%w at %s: the node rejects its pre-EIP-155 deployment transaction, run it with --rpc.allow-unprotected-txs or set create2_factory to a deployed factory: %w",
+				errMissingCreate2Factory, DefaultCreate2Factory.Hex(), err)
+		}
+		return err
+	}
+
+	signer, err := f.Signer(ctx, SuaveChain)
+	if err != nil {
+		return err
+	}
+	_, err = f.waitSuccess(ctx, newPendingTx(f.eth, signer.ChainID(), create2FactoryDeployer, txn, hash), &txOptions{})
+	return err
+}
+
+// fundCreate2FactoryDeployer tops up the keyless deployer to cost, the gas
+// limit times the gas price of its deployment transaction.
+func (f *Framework) fundCreate2FactoryDeployer(ctx context.Context, cost *big.Int) error {
+	balance, err := f.BalanceContext(ctx, create2FactoryDeployer)
+	if err != nil {
+		return err
+	}
+	if balance.Cmp(cost) >= 0 {
+		return nil
+	}
+
+	o := &txOptions{value: new(big.Int).Sub(cost, balance)}
+	pending, err := f.sendTransaction(ctx, f.config.FundedAccount, &create2FactoryDeployer, nil, o)
+	if err != nil {
+		return err
+	}
+	_, err = f.waitSuccess(ctx, pending, o)
+	return err
+}
+
+// deployCreate2 deploys initCode through the factory unless the predicted
+// address already has code. As the address commits to the init code, existing
+// code there was deployed from the same artifact, arguments and libraries.
+// The receipt is nil when the deployment is skipped.
+func (f *Framework) deployCreate2(ctx context.Context, salt common.Hash, initCode []byte, value *big.Int) (common.Address, *types.Receipt, error) {
+	addr := crypto.CreateAddress2(f.config.Create2Factory, salt, crypto.Keccak256(initCode))
+
+	code, err := f.eth.CodeAt(ctx, addr, nil)
+	if err != nil {
+		return common.Address{}, nil, wrapRPCError("eth_getCode", err)
+	}
+	if len(code) != 0 {
+		return addr, nil, nil
+	}
+
+	if err := f.EnsureCreate2Factory(ctx); err != nil {
+		return common.Address{}, nil, err
+	}
+
+	factory := f.config.Create2Factory
+	o := &txOptions{value: value}
+	pending, err := f.sendTransaction(ctx, f.config.FundedAccount, &factory, append(salt.Bytes(), initCode...), o)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	receipt, err := f.waitSuccess(ctx, pending, o)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+
+	// factories other than the default one may not revert on a failed creation
+	code, err = f.eth.CodeAt(ctx, addr, nil)
+	if err != nil {
+		return common.Address{}, nil, wrapRPCError("eth_getCode", err)
+	}
+	if len(code) == 0 {
+		return common.Address{}, nil, fmt.Errorf("%w at %s", errCreate2Deploy, addr.Hex())
+	}
+	return addr, receipt, nil
+}
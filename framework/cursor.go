@@ -0,0 +1,115 @@
+package framework
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Cursor is the position of the last log handled by a subscriber.
+type Cursor struct {
+	Block     uint64      `json:"block"`
+	BlockHash common.Hash `json:"blockHash"`
+	Index     uint        `json:"index"`
+}
+
+// CursorStore persists subscriber cursors by key.
+type CursorStore interface {
+	// Load returns the cursor saved for key, or nil if there is none.
+	Load(key string) (*Cursor, error)
+	Save(key string, cursor Cursor) error
+}
+
+// WithCursor resumes the subscriber from the cursor saved in store under key
+// and saves the cursor after each handled log. An empty key is derived from
+// the network, the contract addresses and the events of the subscriber.
+// A saved cursor takes precedence over WithStartBlock.
+func WithCursor(store CursorStore, key string) SubscriberOption {
+	return func(o *subscriberOptions) {
+		o.cursors = store
+		o.cursorKey = key
+	}
+}
+
+// DefaultCursorPath returns cursors.json next to DefaultArtifactsDir.
+func DefaultCursorPath() string {
+	return filepath.Join(filepath.Dir(DefaultArtifactsDir()), "cursors.json")
+}
+
+// cursorFile is the JSON file layout, cursors by key.
+type cursorFile struct {
+	Cursors map[string]Cursor `json:"cursors"`
+}
+
+// FileCursorStore stores cursors in a JSON file.
+type FileCursorStore struct {
+	path string
+	lock sync.Mutex
+}
+
+func NewFileCursorStore(path string) *FileCursorStore {
+	return &FileCursorStore{path: path}
+}
+
+func (s *FileCursorStore) Load(key string) (*Cursor, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	f, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	cursor, ok := f.Cursors[key]
+	if !ok {
+		return nil, nil
+	}
+	return &cursor, nil
+}
+
+func (s *FileCursorStore) Save(key string, cursor Cursor) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// other processes may save their own cursors in the same file
+	unlock, err := lockFile(s.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := s.load()
+	if err != nil {
+		return err
+	}
+	f.Cursors[key] = cursor
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data)
+}
+
+func (s *FileCursorStore) load() (*cursorFile, error) {
+	f := &cursorFile{Cursors: map[string]Cursor{}}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("invalid cursor file %s: %w", s.path, err)
+	}
+	if f.Cursors == nil {
+		f.Cursors = map[string]Cursor{}
+	}
+	return f, nil
+}
@@ -0,0 +1,159 @@
+package framework
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/suave/sdk"
+)
+
+type deployOptions struct {
+	args      []interface{}
+	libraries map[string]common.Address
+	value     *big.Int
+	salt      *common.Hash
+	name      string
+}
+
+func newDeployOptions(opts []DeployOption) *deployOptions {
+	o := &deployOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// deployOptions returns the options of a deployment, salted with
+// Config.DeploySalt unless WithSalt is given.
+func (f *Framework) deployOptions(opts []DeployOption) *deployOptions {
+	o := newDeployOptions(opts)
+	if o.salt == nil && f.config.DeploySalt != "" {
+		salt := Create2Salt(f.config.DeploySalt)
+		o.salt = &salt
+	}
+	return o
+}
+
+// initCode returns the linked creation code followed by the constructor arguments.
+func (o *deployOptions) initCode(artifact *Artifact) ([]byte, error) {
+	code, err := artifact.Link(o.libraries)
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := artifact.Abi.Pack("", o.args...)
+	if err != nil {
+		return nil, &ABIError{Method: "constructor", Err: err}
+	}
+	return append(code, input...), nil
+}
+
+// DeployOption customizes a contract deployment.
+type DeployOption func(*deployOptions)
+
+// WithConstructorArgs sets the arguments encoded against the constructor of the artifact ABI.
+func WithConstructorArgs(args ...interface{}) DeployOption {
+	return func(o *deployOptions) {
+		o.args = args
+	}
+}
+
+// WithLibrary links the library name, either "Lib" or "file.sol:Lib", to addr.
+// Artifacts that only have the solc placeholder of a library, with no link
+// references naming it, need the fully qualified "file.sol:Lib" name.
+func WithLibrary(name string, addr common.Address) DeployOption {
+	return func(o *deployOptions) {
+		if o.libraries == nil {
+			o.libraries = map[string]common.Address{}
+		}
+		o.libraries[name] = addr
+	}
+}
+
+// WithDeployValue sends value to the constructor, which must be payable.
+func WithDeployValue(value *big.Int) DeployOption {
+	return func(o *deployOptions) {
+		o.value = value
+	}
+}
+
+// WithName records the deployment in the manifest under name. DeployContract
+// defaults it to the artifact file name, e.g. "OpBuilder". DeployArtifact
+// refuses it, as ContractByName could not read the artifact back.
+func WithName(name string) DeployOption {
+	return func(o *deployOptions) {
+		o.name = name
+	}
+}
+
+func (f *Framework) DeployArtifact(artifact *Artifact, opts ...DeployOption) *Contract {
+	contract, err := f.DeployArtifactContext(context.Background(), artifact, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return contract
+}
+
+// DeployArtifactContext links and deploys artifact from the funded account.
+func (f *Framework) DeployArtifactContext(ctx context.Context, artifact *Artifact, opts ...DeployOption) (*Contract, error) {
+	return f.deployArtifact(ctx, artifact, "", opts...)
+}
+
+// deployArtifact deploys artifact and, if it has a name, records it in the
+// manifest with the path it was read from.
+func (f *Framework) deployArtifact(ctx context.Context, artifact *Artifact, path string, opts ...DeployOption) (*Contract, error) {
+	o := f.deployOptions(opts)
+	if o.name != "" && path == "" {
+		return nil, fmt.Errorf("%w: deployment %s has no artifact path to record, deploy it with DeployContract", ErrInvalidArtifact, o.name)
+	}
+
+	initCode, err := o.initCode(artifact)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		addr    common.Address
+		receipt *types.Receipt
+	)
+	if o.salt != nil {
+		if addr, receipt, err = f.deployCreate2(ctx, *o.salt, initCode, o.value); err != nil {
+			return nil, err
+		}
+	} else {
+		// deploy contract
+		txOpts := &txOptions{value: o.value}
+		pending, err := f.sendTransaction(ctx, f.config.FundedAccount, nil, initCode, txOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		if receipt, err = f.waitSuccess(ctx, pending, txOpts); err != nil {
+			return nil, err
+		}
+		addr = receipt.ContractAddress
+	}
+
+	if o.name != "" {
+		deployment := &Deployment{
+			Network:      f.config.Network.Name,
+			Name:         o.name,
+			Artifact:     path,
+			ArtifactHash: artifact.Hash(),
+			Address:      addr,
+		}
+		if receipt != nil {
+			deployment.TxHash = receipt.TxHash
+			deployment.Block = receipt.BlockNumber.Uint64()
+		}
+		if err := f.registry.Record(deployment); err != nil {
+			return nil, err
+		}
+	}
+
+	contract := sdk.GetContract(addr, artifact.Abi, f.clt)
+	return &Contract{addr: addr, fr: f, abi: artifact.Abi, key: f.config.FundedAccount, Contract: contract}, nil
+}
@@ -0,0 +1,127 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	// ErrRPCUnavailable is matched by errors caused by the node not being reachable,
+	// as opposed to the node answering the request with an error.
+	ErrRPCUnavailable = errors.New("rpc unavailable")
+
+	// ErrReceiptTimeout is returned when a transaction receipt is not available
+	// before the context deadline.
+	ErrReceiptTimeout = errors.New("timeout waiting for receipt")
+
+	// ErrABIMismatch is matched by errors raised while packing or unpacking
+	// call data against the contract ABI.
+	ErrABIMismatch = errors.New("abi mismatch")
+
+	// ErrTxReverted is matched by errors for transactions mined with a failed status.
+	ErrTxReverted = errors.New("transaction reverted")
+
+	// ErrTxDropped is returned when a transaction left the pool of the node
+	// without being mined.
+	ErrTxDropped = errors.New("transaction dropped")
+
+	// ErrTxReplaced is returned when another transaction with the same nonce
+	// was mined instead of the awaited one.
+	ErrTxReplaced = errors.New("transaction replaced")
+)
+
+// RPCError wraps a failed request to the node.
+type RPCError struct {
+	Method string
+	Err    error
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc %s: %v", e.Method, e.Err)
+}
+
+func (e *RPCError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports the error as ErrRPCUnavailable unless the node replied
+// with a JSON-RPC error or the caller cancelled the request.
+func (e *RPCError) Is(target error) bool {
+	if target != ErrRPCUnavailable {
+		return false
+	}
+	var rpcErr rpc.Error
+	if errors.As(e.Err, &rpcErr) {
+		return false
+	}
+	return !errors.Is(e.Err, context.Canceled) && !errors.Is(e.Err, context.DeadlineExceeded)
+}
+
+func wrapRPCError(method string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &RPCError{Method: method, Err: err}
+}
+
+// ABIError wraps an error raised while encoding or decoding data for a method.
+type ABIError struct {
+	Method string
+	Err    error
+}
+
+func (e *ABIError) Error() string {
+	return fmt.Sprintf("abi %s: %v", e.Method, e.Err)
+}
+
+func (e *ABIError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ABIError) Is(target error) bool {
+	return target == ErrABIMismatch
+}
+
+// RevertError is returned when a transaction is mined but its execution failed,
+// or when the node rejects a call or confidential request because it reverted.
+type RevertError struct {
+	// TxHash and Receipt are empty when the execution reverted before mining.
+	TxHash  common.Hash
+	Receipt *types.Receipt
+
+	// Data is the raw revert data, if it could be recovered.
+	Data []byte
+	// Reason is the decoded revert reason, if any.
+	Reason string
+	// Custom and Args are set when Data matches a known custom error.
+	Custom *abi.Error
+	Args   []interface{}
+	// PanicCode is set for Panic(uint256) reverts.
+	PanicCode *big.Int
+}
+
+func (e *RevertError) Error() string {
+	msg := "execution reverted"
+	if e.TxHash != (common.Hash{}) {
+		msg = fmt.Sprintf("transaction %s reverted", e.TxHash.Hex())
+	}
+	switch {
+	case e.Reason != "":
+		return msg + ": " + e.Reason
+	case len(e.Data) != 0:
+		return msg + ": " + hexutil.Encode(e.Data)
+	}
+	return msg
+}
+
+func (e *RevertError) Is(target error) bool {
+	return target == ErrTxReverted
+}
@@ -0,0 +1,194 @@
+package framework
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrUnknownEvent is returned when decoding a log that matches none of the
+// events known to an EventDecoder.
+var ErrUnknownEvent = errors.New("unknown event")
+
+// EventDecoder decodes logs of the events declared in a set of artifacts.
+// Logs are matched to events by their first topic.
+type EventDecoder struct {
+	events map[common.Hash]abi.Event
+}
+
+// NewEventDecoder returns a decoder for the events of the artifacts.
+func NewEventDecoder(artifacts ...*Artifact) *EventDecoder {
+	abis := make([]*abi.ABI, len(artifacts))
+	for i, artifact := range artifacts {
+		abis[i] = artifact.Abi
+	}
+	return NewEventDecoderABI(abis...)
+}
+
+// NewEventDecoderABI returns a decoder for the events of the ABIs.
+func NewEventDecoderABI(abis ...*abi.ABI) *EventDecoder {
+	d := &EventDecoder{events: map[common.Hash]abi.Event{}}
+	for _, contractAbi := range abis {
+		for _, event := range contractAbi.Events {
+			if event.Anonymous {
+				continue
+			}
+			if _, ok := d.events[event.ID]; !ok {
+				d.events[event.ID] = event
+			}
+		}
+	}
+	return d
+}
+
+// Event returns the event of log.
+func (d *EventDecoder) Event(log *types.Log) (*abi.Event, error) {
+	if len(log.Topics) == 0 {
+		return nil, fmt.Errorf("%w: log without topics", ErrUnknownEvent)
+	}
+	event, ok := d.events[log.Topics[0]]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEvent, log.Topics[0].Hex())
+	}
+	return &event, nil
+}
+
+// Decode decodes log into out, a pointer to a struct, and returns the name of
+// the event. Event inputs are stored in the field tagged `abi:"<input name>"`
+// or, without a tag, in the field named after the input in camel case.
+// Inputs without a field are ignored. Indexed inputs of dynamic types are
+// only available as their hash, in a common.Hash field.
+func (d *EventDecoder) Decode(log *types.Log, out interface{}) (string, error) {
+	event, err := d.Event(log)
+	if err != nil {
+		return "", err
+	}
+	return event.Name, unpackEvent(event, log, out)
+}
+
+// DecodeEvent is like Decode but fails unless log is an event named name.
+func (d *EventDecoder) DecodeEvent(log *types.Log, name string, out interface{}) error {
+	event, err := d.Event(log)
+	if err != nil {
+		return err
+	}
+	if event.Name != name {
+		return &ABIError{Method: name, Err: fmt.Errorf("log is a %s event", event.Name)}
+	}
+	return unpackEvent(event, log, out)
+}
+
+// DecodeMap decodes log into a map keyed by input name and returns the name
+// of the event. Unnamed inputs are keyed by position, e.g. "arg1".
+func (d *EventDecoder) DecodeMap(log *types.Log) (string, map[string]interface{}, error) {
+	event, err := d.Event(log)
+	if err != nil {
+		return "", nil, err
+	}
+	fields, err := unpackEventMap(event, log)
+	if err != nil {
+		return "", nil, err
+	}
+	return event.Name, fields, nil
+}
+
+// unpackEventMap decodes log into a map keyed by input name. Unnamed inputs
+// are keyed by position, e.g. "arg1", as in the generated bindings.
+func unpackEventMap(event *abi.Event, log *types.Log) (map[string]interface{}, error) {
+	inputs := make(abi.Arguments, len(event.Inputs))
+	for i, input := range event.Inputs {
+		if input.Name == "" {
+			input.Name = fmt.Sprintf("arg%d", i)
+		}
+		inputs[i] = input
+	}
+
+	fields := map[string]interface{}{}
+	if err := inputs.NonIndexed().UnpackIntoMap(fields, log.Data); err != nil {
+		return nil, &ABIError{Method: event.Name, Err: err}
+	}
+
+	var indexed abi.Arguments
+	for _, input := range inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if len(log.Topics)-1 != len(indexed) {
+		return nil, &ABIError{Method: event.Name, Err: fmt.Errorf("log has %d indexed topics, want %d", len(log.Topics)-1, len(indexed))}
+	}
+	if err := abi.ParseTopicsIntoMap(fields, indexed, log.Topics[1:]); err != nil {
+		return nil, &ABIError{Method: event.Name, Err: err}
+	}
+	return fields, nil
+}
+
+func unpackEvent(event *abi.Event, log *types.Log, out interface{}) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return &ABIError{Method: event.Name, Err: fmt.Errorf("cannot decode into %T, want a pointer to a struct", out)}
+	}
+
+	fields, err := unpackEventMap(event, log)
+	if err != nil {
+		return err
+	}
+
+	v = v.Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Tag.Get("abi")
+		if name == "-" {
+			continue
+		}
+		value, ok := fields[name]
+		if name == "" {
+			value, ok = lookupEventField(fields, field.Name)
+		}
+		if !ok {
+			continue
+		}
+		if err := setEventField(v.Field(i), value); err != nil {
+			return &ABIError{Method: event.Name, Err: fmt.Errorf("field %s: %w", field.Name, err)}
+		}
+	}
+	return nil
+}
+
+// lookupEventField finds the input stored in the untagged field name.
+func lookupEventField(fields map[string]interface{}, name string) (interface{}, bool) {
+	for input, value := range fields {
+		if abi.ToCamelCase(input) == name {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func setEventField(dst reflect.Value, value interface{}) (err error) {
+	src := reflect.ValueOf(value)
+	switch {
+	case src.Type().AssignableTo(dst.Type()):
+		dst.Set(src)
+		return nil
+	case src.Type().ConvertibleTo(dst.Type()) && src.Kind() == dst.Kind():
+		dst.Set(src.Convert(dst.Type()))
+		return nil
+	}
+
+	// tuples decode into anonymous structs, let the abi package copy them
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cannot store %s in %s", src.Type(), dst.Type())
+		}
+	}()
+	dst.Set(reflect.ValueOf(abi.ConvertType(value, reflect.New(dst.Type()).Interface())).Elem())
+	return nil
+}
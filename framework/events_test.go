@@ -0,0 +1,59 @@
+package framework
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const eventsABI = `[
+	{"type": "event", "name": "Labelled", "inputs": [
+		{"name": "label", "type": "string", "indexed": true},
+		{"name": "value", "type": "uint256", "indexed": false}
+	]},
+	{"type": "event", "name": "Unnamed", "inputs": [
+		{"name": "", "type": "address", "indexed": true},
+		{"name": "", "type": "uint256", "indexed": false}
+	]}
+]`
+
+func TestUnpackEventIndexedAndUnnamed(t *testing.T) {
+	contractAbi, err := abi.JSON(bytes.NewReader([]byte(eventsABI)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	value := common.LeftPadBytes(big.NewInt(7).Bytes(), 32)
+
+	labelled := contractAbi.Events["Labelled"]
+	label := crypto.Keccak256Hash([]byte("label"))
+	var gotLabelled struct {
+		Label common.Hash
+		Value *big.Int
+	}
+	log := &types.Log{Topics: []common.Hash{labelled.ID, label}, Data: value}
+	if err := unpackEvent(&labelled, log, &gotLabelled); err != nil {
+		t.Fatal(err)
+	}
+	if gotLabelled.Label != label || gotLabelled.Value.Int64() != 7 {
+		t.Errorf("got %+v", gotLabelled)
+	}
+
+	unnamed := contractAbi.Events["Unnamed"]
+	addr := common.HexToAddress("0x1234")
+	var gotUnnamed struct {
+		Arg0 common.Address
+		Arg1 *big.Int
+	}
+	log = &types.Log{Topics: []common.Hash{unnamed.ID, common.BytesToHash(addr.Bytes())}, Data: value}
+	if err := unpackEvent(&unnamed, log, &gotUnnamed); err != nil {
+		t.Fatal(err)
+	}
+	if gotUnnamed.Arg0 != addr || gotUnnamed.Arg1 == nil || gotUnnamed.Arg1.Int64() != 7 {
+		t.Errorf("got %+v", gotUnnamed)
+	}
+}
@@ -0,0 +1,191 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var errNoDynamicFees = errors.New("chain does not support dynamic fee transactions")
+
+// FeeSource is the node fees are estimated from. *ethclient.Client implements it.
+type FeeSource interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+}
+
+// Fees are the gas prices suggested for a transaction. GasTipCap and
+// GasFeeCap are nil on chains without a base fee.
+type Fees struct {
+	GasPrice  *big.Int
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+}
+
+// SuggestFees estimates the fees of a transaction from the latest block. On
+// London chains the tip is the one suggested by the node and the fee cap
+// covers it plus twice the base fee, so that the transaction stays valid for
+// a few full blocks.
+func SuggestFees(ctx context.Context, source FeeSource) (*Fees, error) {
+	head, err := source.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, wrapRPCError("eth_getBlockByNumber", err)
+	}
+
+	if head.BaseFee == nil {
+		gasPrice, err := source.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, wrapRPCError("eth_gasPrice", err)
+		}
+		return &Fees{GasPrice: gasPrice}, nil
+	}
+
+	tip, err := source.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, wrapRPCError("eth_maxPriorityFeePerGas", err)
+	}
+	return &Fees{
+		GasPrice:  new(big.Int).Add(head.BaseFee, tip),
+		GasTipCap: tip,
+		GasFeeCap: new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip),
+	}, nil
+}
+
+// bump returns the fees raised by percent, rounded up.
+func (f *Fees) bump(percent uint64) *Fees {
+	raise := func(fee *big.Int) *big.Int {
+		if fee == nil {
+			return nil
+		}
+		raised := new(big.Int).Mul(fee, new(big.Int).SetUint64(100+percent))
+		raised.Add(raised, big.NewInt(99))
+		return raised.Div(raised, big.NewInt(100))
+	}
+	return &Fees{GasPrice: raise(f.GasPrice), GasTipCap: raise(f.GasTipCap), GasFeeCap: raise(f.GasFeeCap)}
+}
+
+// txFees resolves the type and fees of a transaction, estimating the fees not
+// set by the options.
+func (o *txOptions) txFees(ctx context.Context, source FeeSource) (uint8, *Fees, error) {
+	fees := &Fees{GasPrice: o.gasPrice, GasTipCap: o.gasTipCap, GasFeeCap: o.gasFeeCap}
+
+	txType, typed := o.requestedTxType()
+	dynamic := txType == types.DynamicFeeTxType
+	switch {
+	case typed && dynamic && fees.GasTipCap != nil && fees.GasFeeCap != nil:
+		return txType, fees, nil
+	case typed && !dynamic && fees.GasPrice != nil:
+		return txType, fees, nil
+	}
+
+	suggested, err := SuggestFees(ctx, source)
+	if err != nil {
+		return 0, nil, err
+	}
+	if !typed && suggested.GasFeeCap != nil {
+		txType, dynamic = types.DynamicFeeTxType, true
+	}
+
+	if !dynamic {
+		if fees.GasPrice == nil {
+			fees.GasPrice = suggested.GasPrice
+		}
+		return txType, fees, nil
+	}
+	if suggested.GasFeeCap == nil {
+		return 0, nil, errNoDynamicFees
+	}
+	if fees.GasTipCap == nil {
+		fees.GasTipCap = suggested.GasTipCap
+	}
+	if fees.GasFeeCap == nil {
+		// keep the base fee margin of the estimate on top of the tip
+		fees.GasFeeCap = new(big.Int).Add(new(big.Int).Sub(suggested.GasFeeCap, suggested.GasTipCap), fees.GasTipCap)
+	}
+	return txType, fees, nil
+}
+
+// requestedTxType returns the transaction type set or implied by the options.
+// Without one, the type paying a gas price is returned with typed false.
+func (o *txOptions) requestedTxType() (txType uint8, typed bool) {
+	switch {
+	case o.txType != nil:
+		return *o.txType, true
+	case o.gasTipCap != nil || o.gasFeeCap != nil:
+		return types.DynamicFeeTxType, true
+	case o.gasPrice != nil:
+		return o.priceTxType(), true
+	}
+	return o.priceTxType(), false
+}
+
+// priceTxType is the type of a transaction paying a gas price.
+func (o *txOptions) priceTxType() uint8 {
+	if o.accessList != nil {
+		return types.AccessListTxType
+	}
+	return types.LegacyTxType
+}
+
+// callMsg is the message estimating the gas of the transaction.
+func (o *txOptions) callMsg(from common.Address, to *common.Address, data []byte, txType uint8, fees *Fees) ethereum.CallMsg {
+	msg := ethereum.CallMsg{
+		From:       from,
+		To:         to,
+		Value:      o.value,
+		Data:       data,
+		AccessList: o.accessList,
+	}
+	if txType == types.DynamicFeeTxType {
+		msg.GasTipCap = fees.GasTipCap
+		msg.GasFeeCap = fees.GasFeeCap
+	} else {
+		msg.GasPrice = fees.GasPrice
+	}
+	return msg
+}
+
+// txData builds the transaction of the given type.
+func (o *txOptions) txData(chainID *big.Int, nonce uint64, to *common.Address, data []byte, gas uint64, txType uint8, fees *Fees) (types.TxData, error) {
+	switch txType {
+	case types.LegacyTxType:
+		return &types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: fees.GasPrice,
+			Gas:      gas,
+			To:       to,
+			Value:    o.value,
+			Data:     data,
+		}, nil
+	case types.AccessListTxType:
+		return &types.AccessListTx{
+			ChainID:    chainID,
+			Nonce:      nonce,
+			GasPrice:   fees.GasPrice,
+			Gas:        gas,
+			To:         to,
+			Value:      o.value,
+			Data:       data,
+			AccessList: o.accessList,
+		}, nil
+	case types.DynamicFeeTxType:
+		return &types.DynamicFeeTx{
+			ChainID:    chainID,
+			Nonce:      nonce,
+			GasTipCap:  fees.GasTipCap,
+			GasFeeCap:  fees.GasFeeCap,
+			Gas:        gas,
+			To:         to,
+			Value:      o.value,
+			Data:       data,
+			AccessList: o.accessList,
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported transaction type %d", txType)
+}
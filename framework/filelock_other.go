@@ -0,0 +1,9 @@
+//go:build !unix
+
+package framework
+
+// lockFile is a no-op on platforms without flock, where only the writers of
+// one process are serialized.
+func lockFile(path string) (func(), error) {
+	return func() {}, nil
+}
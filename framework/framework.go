@@ -3,17 +3,14 @@ package framework
 import (
 	"context"
 	"crypto/ecdsa"
-	"encoding/hex"
-	"encoding/json"
 	"fmt"
 	"math/big"
-	"os"
-	"path/filepath"
-	"runtime"
+	"strings"
+	"sync"
 
-	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -21,47 +18,6 @@ import (
 	"github.com/ethereum/go-ethereum/suave/sdk"
 )
 
-type Artifact struct {
-	Abi *abi.ABI
-
-	// Code is the code to deploy the contract
-	Code []byte
-}
-
-func ReadArtifact(path string) (*Artifact, error) {
-	_, filename, _, ok := runtime.Caller(0)
-	if !ok {
-		return nil, fmt.Errorf("unable to get the current filename")
-	}
-	dirname := filepath.Dir(filename)
-
-	data, err := os.ReadFile(filepath.Join(dirname, "../out", path))
-	if err != nil {
-		return nil, err
-	}
-
-	var artifact struct {
-		Abi      *abi.ABI `json:"abi"`
-		Bytecode struct {
-			Object string `json:"object"`
-		} `json:"bytecode"`
-	}
-	if err := json.Unmarshal(data, &artifact); err != nil {
-		return nil, err
-	}
-
-	code, err := hex.DecodeString(artifact.Bytecode.Object[2:])
-	if err != nil {
-		return nil, err
-	}
-
-	art := &Artifact{
-		Abi:  artifact.Abi,
-		Code: code,
-	}
-	return art, nil
-}
-
 type PrivKey struct {
 	Priv *ecdsa.PrivateKey
 }
@@ -74,20 +30,38 @@ func (p *PrivKey) MarshalPrivKey() []byte {
 	return crypto.FromECDSA(p.Priv)
 }
 
-func NewPrivKeyFromHex(hex string) *PrivKey {
+// ParsePrivKey parses a hex encoded private key.
+func ParsePrivKey(hex string) (*PrivKey, error) {
 	key, err := crypto.HexToECDSA(hex)
 	if err != nil {
-		panic(fmt.Sprintf("failed to parse private key: %v", err))
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
 	}
-	return &PrivKey{Priv: key}
+	return &PrivKey{Priv: key}, nil
 }
 
-func GeneratePrivKey() *PrivKey {
+func NewPrivKeyFromHex(hex string) *PrivKey {
+	key, err := ParsePrivKey(hex)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// NewPrivKey generates a new random private key.
+func NewPrivKey() (*PrivKey, error) {
 	key, err := crypto.GenerateKey()
 	if err != nil {
-		panic(fmt.Sprintf("failed to generate private key: %v", err))
+		return nil, fmt.Errorf("failed to generate private key: %w", err)
+	}
+	return &PrivKey{Priv: key}, nil
+}
+
+func GeneratePrivKey() *PrivKey {
+	key, err := NewPrivKey()
+	if err != nil {
+		panic(err)
 	}
-	return &PrivKey{Priv: key}
+	return key
 }
 
 type Contract struct {
@@ -96,134 +70,160 @@ type Contract struct {
 	addr common.Address
 	abi  *abi.ABI
 	fr   *Framework
+	key  *PrivKey
 }
 
 func (c *Contract) Call(methodName string) []interface{} {
-	input, err := c.abi.Pack(methodName)
-	if err != nil {
-		panic(err)
-	}
-
-	callMsg := ethereum.CallMsg{
-		To:   &c.addr,
-		Data: input,
-	}
-	rpcClient := ethclient.NewClient(c.fr.rpc)
-	output, err := rpcClient.CallContract(context.Background(), callMsg, nil)
-	if err != nil {
-		panic(err)
-	}
-
-	results, err := c.abi.Methods[methodName].Outputs.Unpack(output)
+	results, err := c.CallContext(context.Background(), methodName)
 	if err != nil {
 		panic(err)
 	}
 	return results
 }
 
+// CallContext executes a read-only call of methodName against the latest block.
+func (c *Contract) CallContext(ctx context.Context, methodName string) ([]interface{}, error) {
+	return c.Read(ctx, methodName, nil)
+}
+
 func (c *Contract) CallWithArgs(methodName string, args []interface{}) []interface{} {
-	input, err := c.abi.Pack(methodName, args)
+	results, err := c.CallWithArgsContext(context.Background(), methodName, args)
 	if err != nil {
 		panic(err)
 	}
+	return results
+}
 
-	callMsg := ethereum.CallMsg{
-		To:   &c.addr,
-		Data: input,
-	}
-	rpcClient := ethclient.NewClient(c.fr.rpc)
-	output, err := rpcClient.CallContract(context.Background(), callMsg, nil)
-	if err != nil {
-		panic(err)
-	}
+// CallWithArgsContext is like CallContext but packs args as the method inputs.
+func (c *Contract) CallWithArgsContext(ctx context.Context, methodName string, args []interface{}) ([]interface{}, error) {
+	return c.Read(ctx, methodName, args)
+}
 
-	results, err := c.abi.Methods[methodName].Outputs.Unpack(output)
+func (c *Contract) SendTransaction(method string, args []interface{}, confidentialBytes []byte, opts ...TxOption) *types.Receipt {
+	receipt, err := c.SendTransactionContext(context.Background(), method, args, confidentialBytes, opts...)
 	if err != nil {
+		fmt.Println("failed to send transaction", "err", err)
 		panic(err)
 	}
-	return results
+	return receipt
 }
 
-func (c *Contract) SendTransaction(method string, args []interface{}, confidentialBytes []byte) *types.Receipt {
-	txnResult, err := c.Contract.SendTransaction(method, args, confidentialBytes)
+// SendTransactionContext sends a confidential compute request for method and
+// waits for its receipt, see WithReceiptConfirmations. A receipt with a failed
+// status is returned together with a *RevertError.
+func (c *Contract) SendTransactionContext(ctx context.Context, method string, args []interface{}, confidentialBytes []byte, opts ...TxOption) (*types.Receipt, error) {
+	calldata, err := c.abi.Pack(method, args...)
 	if err != nil {
-		fmt.Println("failed to send transaction", "err", err)
-		panic(err)
+		return nil, &ABIError{Method: method, Err: err}
 	}
-	receipt, err := txnResult.Wait()
+
+	o := newTxOptions(opts)
+	pending, err := c.fr.sendConfidentialRequest(ctx, c.key, c.addr, calldata, confidentialBytes, o)
 	if err != nil {
-		fmt.Println("failed to wait for transaction", "err", err)
-		panic(err)
+		return nil, err
 	}
 
-	if receipt.Status == 0 {
-		panic("bad")
-	}
-	return receipt
+	return c.fr.waitSuccess(ctx, pending, o)
 }
 
 type Framework struct {
 	config *Config
 	rpc    *rpc.Client
+	eth    *ethclient.Client
 	clt    *sdk.Client
-}
 
-type Config struct {
-	KettleRPC     string
-	KettleAddr    common.Address
-	FundedAccount *PrivKey
+	artifacts *ArtifactLoader
+	registry  *Registry
+	errors    *errorRegistry
+	nonces    *NonceManager
+	conns     *Connections
+	ownConns  bool
+
+	lock    sync.Mutex
+	signers map[Chain]*ChainSigner
 }
 
-func DefaultConfig() *Config {
-	return &Config{
-		KettleRPC:  "http://localhost:11545",
-		KettleAddr: common.HexToAddress("b5feafbdd752ad52afb7e1bd2e40432a485bbb7f"),
+func New() *Framework {
+	fr, err := NewContext(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	return fr
+}
 
-		// This account is funded in both devnev networks
-		// address: 0xBE69d72ca5f88aCba033a063dF5DBe43a4148De0
-		FundedAccount: NewPrivKeyFromHex("91ab9a7e53c220e6210460b65a7a3bb2ca181412a8a7b43ff336b3df1737ce12"),
+// NewContext connects to the kettle using the config returned by LoadConfig,
+// so the defaults can be overridden with SUAPP_* environment variables.
+func NewContext(ctx context.Context) (*Framework, error) {
+	config, err := LoadConfig("")
+	if err != nil {
+		return nil, err
 	}
+	return NewWithConfigContext(ctx, config)
 }
 
-func New() *Framework {
-	config := DefaultConfig()
+// NewWithConfig connects to the kettle described by config.
+func NewWithConfig(config *Config) (*Framework, error) {
+	return NewWithConfigContext(context.Background(), config)
+}
+
+// NewWithConfigContext is like NewWithConfig but dials with ctx.
+func NewWithConfigContext(ctx context.Context, config *Config) (*Framework, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
 
-	rpc, _ := rpc.Dial(config.KettleRPC)
+	conns := config.Connections
+	if conns == nil {
+		conns = NewConnections()
+	}
+	rpc, err := conns.Client(ctx, config.KettleRPC)
+	if err != nil {
+		return nil, err
+	}
 	clt := sdk.NewClient(rpc, config.FundedAccount.Priv, config.KettleAddr)
 
+	nonces := config.Nonces
+	if nonces == nil {
+		nonces = DefaultNonceManager()
+	}
+
 	return &Framework{
-		config: DefaultConfig(),
+		config: config,
 		rpc:    rpc,
+		eth:    ethclient.NewClient(rpc),
 		clt:    clt,
-	}
+
+		artifacts: config.artifactLoader(),
+		registry:  NewRegistry(config.manifestPath()),
+		errors:    newErrorRegistry(),
+		nonces:    nonces,
+		conns:     conns,
+		ownConns:  config.Connections == nil,
+		signers:   map[Chain]*ChainSigner{},
+	}, nil
 }
 
 func (f *Framework) ContractAt(addr common.Address, abi *abi.ABI) *Contract {
-	return &Contract{addr: addr, fr: f, abi: abi, Contract: sdk.GetContract(addr, abi, f.clt)}
+	f.errors.register(abi)
+	return &Contract{addr: addr, fr: f, abi: abi, key: f.config.FundedAccount, Contract: sdk.GetContract(addr, abi, f.clt)}
 }
 
-func (f *Framework) DeployContract(path string) *Contract {
-	artifact, err := ReadArtifact(path)
-	if err != nil {
-		panic(err)
-	}
-
-	// deploy contract
-	txnResult, err := sdk.DeployContract(artifact.Code, f.clt)
+func (f *Framework) DeployContract(path string, opts ...DeployOption) *Contract {
+	contract, err := f.DeployContractContext(context.Background(), path, opts...)
 	if err != nil {
 		panic(err)
 	}
+	return contract
+}
 
-	receipt, err := txnResult.Wait()
+// DeployContractContext deploys the artifact at path from the funded account.
+func (f *Framework) DeployContractContext(ctx context.Context, path string, opts ...DeployOption) (*Contract, error) {
+	artifact, err := f.ReadArtifact(path)
 	if err != nil {
-		panic(err)
-	}
-	if receipt.Status == 0 {
-		panic(fmt.Errorf("transaction failed"))
+		return nil, err
 	}
-
-	contract := sdk.GetContract(receipt.ContractAddress, artifact.Abi, f.clt)
-	return &Contract{addr: receipt.ContractAddress, fr: f, abi: artifact.Abi, Contract: contract}
+	opts = append([]DeployOption{WithName(contractName(path))}, opts...)
+	return f.deployArtifact(ctx, artifact, path, opts...)
 }
 
 func (c *Contract) Ref(acct *PrivKey) *Contract {
@@ -231,45 +231,103 @@ func (c *Contract) Ref(acct *PrivKey) *Contract {
 		addr:     c.addr,
 		abi:      c.abi,
 		fr:       c.fr,
+		key:      acct,
 		Contract: sdk.GetContract(c.addr, c.abi, c.fr.NewClient(acct)),
 	}
 	return cc
 }
 
+// NewClient returns an sdk client sending from acct over the kettle connection
+// of the framework.
 func (f *Framework) NewClient(acct *PrivKey) *sdk.Client {
-	cc := DefaultConfig()
-	rpc, _ := rpc.Dial(cc.KettleRPC)
-	return sdk.NewClient(rpc, acct.Priv, cc.KettleAddr)
+	return sdk.NewClient(f.rpc, acct.Priv, f.config.KettleAddr)
+}
+
+// Client returns the client of chain, sharing the connections of the
+// framework. The SUAVE chain is served by the kettle.
+func (f *Framework) Client(ctx context.Context, chain Chain) (*ethclient.Client, error) {
+	if chain == SuaveChain {
+		return f.eth, nil
+	}
+	url := f.config.Network.RPC(chain)
+	if url == "" {
+		return nil, fmt.Errorf("%w: network %s has no endpoint for the %s chain", errInvalidConfig, f.config.Network.Name, chain)
+	}
+	return f.conns.Eth(ctx, url)
+}
+
+// Health checks the endpoints the framework is connected to.
+func (f *Framework) Health(ctx context.Context) []Health {
+	return f.conns.Health(ctx)
 }
 
-func (f *Framework) SignTx(priv *PrivKey, tx *types.LegacyTx) (*types.Transaction, error) {
-	rpc, _ := rpc.Dial("http://localhost:11545")
+// Close closes the connections of the framework, unless they were passed
+// with Config.Connections.
+func (f *Framework) Close() {
+	if f.ownConns {
+		f.conns.Close()
+	}
+}
 
-	cltAcct1 := sdk.NewClient(rpc, priv.Priv, common.Address{})
-	signedTxn, err := cltAcct1.SignTxn(tx)
+// ReadArtifact reads an artifact from the artifacts source of the config.
+func (f *Framework) ReadArtifact(path string) (*Artifact, error) {
+	artifact, err := f.artifacts.Read(path)
 	if err != nil {
 		return nil, err
 	}
-	return signedTxn, nil
+	f.errors.register(artifact.Abi)
+	return artifact, nil
 }
 
-var errFundAccount = fmt.Errorf("failed to fund account")
+// RegisterErrors makes the custom errors of contractAbi available to the
+// decoding of reverts. The errors of the artifacts read by the framework and
+// of the contracts bound with ContractAt are registered already.
+func (f *Framework) RegisterErrors(contractAbi *abi.ABI) {
+	f.errors.register(contractAbi)
+}
+
+// Network returns the network profile the framework was built with.
+func (f *Framework) Network() *Network {
+	return f.config.Network
+}
 
-func (f *Framework) FundAccount(to common.Address, value *big.Int) error {
-	txn := &types.LegacyTx{
-		Value: value,
-		To:    &to,
+// Config returns the config the framework was built with.
+func (f *Framework) Config() *Config {
+	return f.config
+}
+
+// SignTx signs tx with priv for the SUAVE chain as is. tx may be a legacy,
+// access list or dynamic fee transaction. Transactions from an account the
+// framework also sends from should take their nonce from NextNonce. Use
+// Signer to sign for the other chains of the network.
+func (f *Framework) SignTx(priv *PrivKey, tx types.TxData) (*types.Transaction, error) {
+	signer, err := f.Signer(context.Background(), SuaveChain)
+	if err != nil {
+		return nil, err
 	}
-	result, err := f.clt.SendTransaction(txn)
+	return signer.SignTx(priv, tx)
+}
+
+var errFundAccount = fmt.Errorf("failed to fund account")
+
+func (f *Framework) FundAccount(to common.Address, value *big.Int, opts ...TxOption) error {
+	return f.FundAccountContext(context.Background(), to, value, opts...)
+}
+
+// FundAccountContext transfers value from the funded account to the given
+// address, with a dynamic fee transaction on London chains by default, and
+// waits for the transfer as set by the options, see WithFeeBump.
+func (f *Framework) FundAccountContext(ctx context.Context, to common.Address, value *big.Int, opts ...TxOption) error {
+	o := newTxOptions(append(opts, WithValue(value)))
+	pending, err := f.sendTransaction(ctx, f.config.FundedAccount, &to, nil, o)
 	if err != nil {
 		return err
 	}
-	_, err = result.Wait()
-	if err != nil {
+	if _, err := f.waitSuccess(ctx, pending, o); err != nil {
 		return err
 	}
 	// check balance
-	balance, err := f.clt.RPC().BalanceAt(context.Background(), to, nil)
+	balance, err := f.BalanceContext(ctx, to)
 	if err != nil {
 		return err
 	}
@@ -279,10 +337,274 @@ func (f *Framework) FundAccount(to common.Address, value *big.Int) error {
 	return nil
 }
 
+// Transact sends a transaction from key to the address to, or creates a
+// contract if to is nil, on chain, e.g. the OP chain of the network. It
+// returns the hash without waiting for the receipt. The nonce, fees and gas
+// limit not set by the options are filled from the endpoint of chain.
+func (f *Framework) Transact(ctx context.Context, chain Chain, key *PrivKey, to *common.Address, data []byte, opts ...TxOption) (common.Hash, error) {
+	signer, err := f.Signer(ctx, chain)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	client, err := f.Client(ctx, chain)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	pending, err := f.transact(ctx, client, signer, key, to, data, newTxOptions(opts))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return pending.hash(), nil
+}
+
 func (f *Framework) Balance(addr common.Address) (*big.Int, error) {
-	balance, err := f.clt.RPC().BalanceAt(context.Background(), addr, nil)
+	return f.BalanceContext(context.Background(), addr)
+}
+
+// BalanceContext returns the balance of addr at the latest block.
+func (f *Framework) BalanceContext(ctx context.Context, addr common.Address) (*big.Int, error) {
+	var balance *big.Int
+	err := f.config.Retry.Do(ctx, func() (err error) {
+		balance, err = f.eth.BalanceAt(ctx, addr, nil)
+		return err
+	})
 	if err != nil {
-		return nil, err
+		return nil, wrapRPCError("eth_getBalance", err)
 	}
 	return balance, nil
 }
+
+// NextNonce reserves the next nonce of addr on the SUAVE chain, e.g. for a
+// transaction signed with SignTx from an account the framework also sends from.
+func (f *Framework) NextNonce(ctx context.Context, addr common.Address) (uint64, error) {
+	signer, err := f.Signer(ctx, SuaveChain)
+	if err != nil {
+		return 0, err
+	}
+	return f.nonces.Next(ctx, f.eth, signer.ChainID(), addr)
+}
+
+// ReleaseNonce gives back a nonce reserved with NextNonce whose transaction
+// was not sent.
+func (f *Framework) ReleaseNonce(ctx context.Context, addr common.Address, nonce uint64) error {
+	signer, err := f.Signer(ctx, SuaveChain)
+	if err != nil {
+		return err
+	}
+	f.nonces.Release(signer.ChainID(), addr, nonce)
+	return nil
+}
+
+// sendTransaction sends a transaction from key to the kettle, see transact.
+func (f *Framework) sendTransaction(ctx context.Context, key *PrivKey, to *common.Address, data []byte, o *txOptions) (*pendingTx, error) {
+	signer, err := f.Signer(ctx, SuaveChain)
+	if err != nil {
+		return nil, err
+	}
+	return f.transact(ctx, f.eth, signer, key, to, data, o)
+}
+
+// transact fills the missing nonce, fees and gas limit of a transaction from
+// eth, signs it with key and broadcasts it through eth.
+func (f *Framework) transact(ctx context.Context, eth *ethclient.Client, signer *ChainSigner, key *PrivKey, to *common.Address, data []byte, o *txOptions) (*pendingTx, error) {
+	senderAddr := key.Address()
+
+	var (
+		txType uint8
+		fees   *Fees
+	)
+	err := f.config.Retry.Do(ctx, func() (err error) {
+		txType, fees, err = o.txFees(ctx, eth)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	gas := o.gas
+	if gas == 0 {
+		err := f.config.Retry.Do(ctx, func() (err error) {
+			gas, err = eth.EstimateGas(ctx, o.callMsg(senderAddr, to, data, txType, fees))
+			return err
+		})
+		if err != nil {
+			return nil, f.callError("eth_estimateGas", err)
+		}
+	}
+
+	signWithFees := func(nonce uint64, fees *Fees) (*types.Transaction, error) {
+		txData, err := o.txData(signer.ChainID(), nonce, to, data, gas, txType, fees)
+		if err != nil {
+			return nil, err
+		}
+		return signer.SignTx(key, txData)
+	}
+	sign := func(nonce uint64) (*types.Transaction, error) {
+		return signWithFees(nonce, fees)
+	}
+
+	var (
+		txn  *types.Transaction
+		hash common.Hash
+	)
+	if o.nonce != nil {
+		txn, hash, err = f.signAndSend(ctx, eth.Client(), sign, *o.nonce)
+	} else {
+		txn, hash, err = f.sendWithNonce(ctx, eth, signer.ChainID(), senderAddr, sign)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pending := newPendingTx(eth, signer.ChainID(), senderAddr, txn, hash)
+	pending.resign = func(percent uint64) (*types.Transaction, error) {
+		fees = fees.bump(percent)
+		return signWithFees(txn.Nonce(), fees)
+	}
+	return pending, nil
+}
+
+// sendConfidentialRequest signs and broadcasts a confidential compute request
+// for the contract at addr. Fields not set by the options are filled from the node.
+func (f *Framework) sendConfidentialRequest(ctx context.Context, key *PrivKey, addr common.Address, calldata, confidentialBytes []byte, o *txOptions) (*pendingTx, error) {
+	signer, err := f.Signer(ctx, SuaveChain)
+	if err != nil {
+		return nil, err
+	}
+
+	gasPrice := o.gasPrice
+	if gasPrice == nil {
+		err := f.config.Retry.Do(ctx, func() (err error) {
+			gasPrice, err = f.eth.SuggestGasPrice(ctx)
+			return err
+		})
+		if err != nil {
+			return nil, wrapRPCError("eth_gasPrice", err)
+		}
+	}
+
+	gas := o.gas
+	if gas == 0 {
+		gas = defaultConfidentialGas
+	}
+
+	sign := func(nonce uint64) (*types.Transaction, error) {
+		return signer.SignTx(key, &types.ConfidentialComputeRequest{
+			ConfidentialComputeRecord: types.ConfidentialComputeRecord{
+				KettleAddress: f.config.KettleAddr,
+				Nonce:         nonce,
+				To:            &addr,
+				Value:         o.value,
+				GasPrice:      gasPrice,
+				Gas:           gas,
+				Data:          calldata,
+			},
+			ConfidentialInputs: confidentialBytes,
+		})
+	}
+
+	var (
+		txn  *types.Transaction
+		hash common.Hash
+	)
+	if o.nonce != nil {
+		txn, hash, err = f.signAndSend(ctx, f.rpc, sign, *o.nonce)
+	} else {
+		txn, hash, err = f.sendWithNonce(ctx, f.eth, signer.ChainID(), key.Address(), sign)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// the returned hash is the one of the SUAVE transaction built by the kettle
+	return newPendingTx(f.eth, signer.ChainID(), key.Address(), txn, hash), nil
+}
+
+// sendWithNonce signs a transaction with the next nonce of sender and
+// broadcasts it through eth. The nonce is resynced and the transaction signed
+// again when the node reports the nonce as taken, and released if it is not sent.
+func (f *Framework) sendWithNonce(ctx context.Context, eth *ethclient.Client, chainID *big.Int, sender common.Address, sign func(nonce uint64) (*types.Transaction, error)) (*types.Transaction, common.Hash, error) {
+	for attempt := 0; ; attempt++ {
+		nonce, err := f.nonces.Next(ctx, eth, chainID, sender)
+		if err != nil {
+			return nil, common.Hash{}, err
+		}
+
+		txn, hash, err := f.signAndSend(ctx, eth.Client(), sign, nonce)
+		if err == nil {
+			return txn, hash, nil
+		}
+		if !isNonceTaken(err) {
+			f.nonces.Release(chainID, sender, nonce)
+			return nil, common.Hash{}, err
+		}
+		if attempt >= maxNonceRetries {
+			return nil, common.Hash{}, err
+		}
+		if err := f.nonces.Resync(ctx, eth, chainID, sender); err != nil {
+			return nil, common.Hash{}, err
+		}
+	}
+}
+
+// signAndSend signs a transaction with nonce and broadcasts it through client.
+// It returns the signed transaction and the hash returned by the node.
+func (f *Framework) signAndSend(ctx context.Context, client *rpc.Client, sign func(nonce uint64) (*types.Transaction, error), nonce uint64) (*types.Transaction, common.Hash, error) {
+	txn, err := sign(nonce)
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	hash, err := f.sendRawTransactionTo(ctx, client, txn)
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	return txn, hash, nil
+}
+
+func (f *Framework) sendRawTransaction(ctx context.Context, txn *types.Transaction) (common.Hash, error) {
+	return f.sendRawTransactionTo(ctx, f.rpc, txn)
+}
+
+// sendRawTransactionTo broadcasts txn through client with the retry policy.
+// Resending the same signed transaction is safe as the node executes it at
+// most once, and a node that already has it counts as a success. Confidential
+// requests are only resent if the kettle never got them, as it would run
+// their confidential computation again.
+func (f *Framework) sendRawTransactionTo(ctx context.Context, client *rpc.Client, txn *types.Transaction) (common.Hash, error) {
+	txnBytes, err := txn.MarshalBinary()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	confidential := txn.Type() == types.ConfidentialComputeRequestTxType
+
+	var (
+		hash      common.Hash
+		attempted bool
+	)
+	retry := func(err error) bool {
+		return f.config.Retry.Retries(err) && (!confidential || unsent(err))
+	}
+	err = f.config.Retry.do(ctx, func() error {
+		err := client.CallContext(ctx, &hash, "eth_sendRawTransaction", hexutil.Encode(txnBytes))
+		// an earlier attempt may have gone through before failing
+		if err != nil && !confidential && (isAlreadyKnown(err) || attempted && isNonceTaken(err) && txKnown(ctx, client, txn.Hash())) {
+			hash, err = txn.Hash(), nil
+		}
+		attempted = true
+		return err
+	}, retry)
+	if err != nil {
+		return common.Hash{}, f.callError("eth_sendRawTransaction", err)
+	}
+	return hash, nil
+}
+
+// isAlreadyKnown reports whether the node rejected a transaction it already has.
+func isAlreadyKnown(err error) bool {
+	return strings.Contains(err.Error(), "already known")
+}
+
+// txKnown reports whether the node has the transaction hash, pending or mined.
+func txKnown(ctx context.Context, client *rpc.Client, hash common.Hash) bool {
+	_, _, err := ethclient.NewClient(client).TransactionByHash(ctx, hash)
+	return err == nil
+}
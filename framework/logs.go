@@ -0,0 +1,93 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ABI returns the ABI the contract is bound to.
+func (c *Contract) ABI() *abi.ABI {
+	return c.abi
+}
+
+// UnpackLog decodes a log of the event named eventName into out, a pointer to
+// a struct laid out as for EventDecoder.Decode.
+func (c *Contract) UnpackLog(out interface{}, eventName string, log types.Log) error {
+	event, ok := c.abi.Events[eventName]
+	if !ok {
+		return &ABIError{Method: eventName, Err: fmt.Errorf("event not found")}
+	}
+	if len(log.Topics) == 0 || log.Topics[0] != event.ID {
+		return &ABIError{Method: eventName, Err: fmt.Errorf("log is not a %s event", eventName)}
+	}
+	return unpackEvent(&event, &log, out)
+}
+
+// FilterLogs returns the logs of the event named eventName emitted by the
+// contract between fromBlock and toBlock. A nil toBlock means the latest block.
+func (c *Contract) FilterLogs(ctx context.Context, eventName string, fromBlock, toBlock *big.Int) ([]types.Log, error) {
+	query, err := c.eventQuery(eventName)
+	if err != nil {
+		return nil, err
+	}
+	query.FromBlock = fromBlock
+	query.ToBlock = toBlock
+
+	logs, err := c.fr.eth.FilterLogs(ctx, query)
+	if err != nil {
+		return nil, wrapRPCError("eth_getLogs", err)
+	}
+	return logs, nil
+}
+
+// WatchLogs streams new logs of the event named eventName to ch through the
+// websocket endpoint of the network. Endpoints without subscriptions are
+// polled by a Subscriber instead.
+func (c *Contract) WatchLogs(ctx context.Context, eventName string, ch chan<- types.Log) (ethereum.Subscription, error) {
+	query, err := c.eventQuery(eventName)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := c.fr.logsEndpoint()
+	client, err := c.fr.conns.Eth(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := client.SubscribeFilterLogs(ctx, query, ch)
+	if errors.Is(err, rpc.ErrNotificationsUnsupported) {
+		return c.fr.NewSubscriber(query, WithEndpoint(endpoint)).Subscribe(ch), nil
+	}
+	if err != nil {
+		return nil, wrapRPCError("eth_subscribe", err)
+	}
+	return sub, nil
+}
+
+// logsEndpoint returns the websocket endpoint of the kettle, or its RPC if
+// the config has none.
+func (f *Framework) logsEndpoint() string {
+	if f.config.KettleWS != "" {
+		return f.config.KettleWS
+	}
+	return f.config.KettleRPC
+}
+
+func (c *Contract) eventQuery(eventName string) (ethereum.FilterQuery, error) {
+	event, ok := c.abi.Events[eventName]
+	if !ok {
+		return ethereum.FilterQuery{}, &ABIError{Method: eventName, Err: fmt.Errorf("event not found")}
+	}
+	return ethereum.FilterQuery{
+		Addresses: []common.Address{c.addr},
+		Topics:    [][]common.Hash{{event.ID}},
+	}, nil
+}
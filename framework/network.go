@@ -0,0 +1,207 @@
+package framework
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Network bundles the endpoints and chain ids a Suapp needs to talk to.
+type Network struct {
+	Name string
+
+	// KettleRPC and KettleWS are the HTTP and websocket endpoints of the kettle.
+	KettleRPC  string
+	KettleWS   string
+	KettleAddr common.Address
+	// SuaveChainID is the SUAVE chain id, zero if it has to be queried from the kettle.
+	SuaveChainID uint64
+
+	// ExecutionRPC is the execution chain (L1) endpoint.
+	ExecutionRPC     string
+	ExecutionChainID uint64
+
+	// OpRPC is the OP stack (L2) endpoint.
+	OpRPC     string
+	OpChainID uint64
+
+	// RelayURLs are the relays blocks are submitted to.
+	RelayURLs []string
+}
+
+// DefaultNetwork is the profile used when none is selected.
+const DefaultNetwork = "local"
+
+// networksLock guards networks, which RegisterNetwork may change while
+// configs are loaded.
+var networksLock sync.RWMutex
+
+var networks = map[string]*Network{
+	// the kettle of the local devnet next to pbs-on-optimism
+	"local": {
+		Name:             "local",
+		KettleRPC:        "http://localhost:11545",
+		KettleWS:         "ws://127.0.0.1:11546",
+		KettleAddr:       common.HexToAddress("b5feafbdd752ad52afb7e1bd2e40432a485bbb7f"),
+		ExecutionRPC:     "http://localhost:8545",
+		ExecutionChainID: 900,
+		OpRPC:            "http://localhost:9545",
+		OpChainID:        901,
+		RelayURLs:        []string{"http://host.docker.internal:18550"},
+	},
+	// the docker compose environment of suave-geth
+	"devenv": {
+		Name:             "devenv",
+		KettleRPC:        "http://localhost:8545",
+		KettleWS:         "ws://127.0.0.1:8546",
+		KettleAddr:       common.HexToAddress("b5feafbdd752ad52afb7e1bd2e40432a485bbb7f"),
+		SuaveChainID:     16813125,
+		ExecutionRPC:     "http://localhost:8555",
+		ExecutionChainID: 1337,
+	},
+}
+
+// RegisterNetwork adds or replaces a named network profile.
+func RegisterNetwork(n *Network) {
+	networksLock.Lock()
+	defer networksLock.Unlock()
+	networks[n.Name] = n
+}
+
+// LookupNetwork returns a copy of the profile registered under name.
+func LookupNetwork(name string) (*Network, error) {
+	return lookupNetwork(name, nil)
+}
+
+// lookupNetwork returns a copy of the profile named name in declared or, if
+// it has none, of the registered one.
+func lookupNetwork(name string, declared map[string]*Network) (*Network, error) {
+	n, ok := declared[name]
+	if !ok {
+		networksLock.RLock()
+		n, ok = networks[name]
+		networksLock.RUnlock()
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown network %q, available: %s", errInvalidConfig, name, strings.Join(networkNames(declared), ", "))
+	}
+	cpy := *n
+	cpy.RelayURLs = append([]string{}, n.RelayURLs...)
+	return &cpy, nil
+}
+
+// NetworkNames returns the names of the registered profiles.
+func NetworkNames() []string {
+	return networkNames(nil)
+}
+
+func networkNames(declared map[string]*Network) []string {
+	set := map[string]struct{}{}
+	for name := range declared {
+		set[name] = struct{}{}
+	}
+	networksLock.RLock()
+	for name := range networks {
+		set[name] = struct{}{}
+	}
+	networksLock.RUnlock()
+
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RelayURL returns the first relay of the network or an empty string.
+func (n *Network) RelayURL() string {
+	if len(n.RelayURLs) == 0 {
+		return ""
+	}
+	return n.RelayURLs[0]
+}
+
+// Chain names one of the chains of a network.
+type Chain int
+
+const (
+	// SuaveChain is the chain of the kettle.
+	SuaveChain Chain = iota
+	// ExecutionChain is the execution chain (L1).
+	ExecutionChain
+	// OpChain is the OP stack chain (L2).
+	OpChain
+)
+
+func (c Chain) String() string {
+	switch c {
+	case SuaveChain:
+		return "suave"
+	case ExecutionChain:
+		return "execution"
+	case OpChain:
+		return "op"
+	}
+	return fmt.Sprintf("chain(%d)", int(c))
+}
+
+// ChainID returns the chain id of chain in the profile, zero if unknown.
+func (n *Network) ChainID(chain Chain) uint64 {
+	switch chain {
+	case SuaveChain:
+		return n.SuaveChainID
+	case ExecutionChain:
+		return n.ExecutionChainID
+	case OpChain:
+		return n.OpChainID
+	}
+	return 0
+}
+
+// RPC returns the HTTP endpoint of chain, empty if the profile has none.
+func (n *Network) RPC(chain Chain) string {
+	switch chain {
+	case SuaveChain:
+		return n.KettleRPC
+	case ExecutionChain:
+		return n.ExecutionRPC
+	case OpChain:
+		return n.OpRPC
+	}
+	return ""
+}
+
+// networkFile is the TOML/YAML representation of a Network.
+type networkFile struct {
+	KettleRPC        string   `toml:"kettle_rpc" yaml:"kettle_rpc"`
+	KettleWS         string   `toml:"kettle_ws" yaml:"kettle_ws"`
+	KettleAddr       string   `toml:"kettle_addr" yaml:"kettle_addr"`
+	SuaveChainID     uint64   `toml:"suave_chain_id" yaml:"suave_chain_id"`
+	ExecutionRPC     string   `toml:"execution_rpc" yaml:"execution_rpc"`
+	ExecutionChainID uint64   `toml:"execution_chain_id" yaml:"execution_chain_id"`
+	OpRPC            string   `toml:"op_rpc" yaml:"op_rpc"`
+	OpChainID        uint64   `toml:"op_chain_id" yaml:"op_chain_id"`
+	RelayURLs        []string `toml:"relay_urls" yaml:"relay_urls"`
+}
+
+func (n *networkFile) toNetwork(name string) (*Network, error) {
+	if n.KettleAddr != "" && !common.IsHexAddress(n.KettleAddr) {
+		return nil, fmt.Errorf("%w: network %s: kettle_addr %q is not an address", errInvalidConfig, name, n.KettleAddr)
+	}
+	return &Network{
+		Name:             name,
+		KettleRPC:        n.KettleRPC,
+		KettleWS:         n.KettleWS,
+		KettleAddr:       common.HexToAddress(n.KettleAddr),
+		SuaveChainID:     n.SuaveChainID,
+		ExecutionRPC:     n.ExecutionRPC,
+		ExecutionChainID: n.ExecutionChainID,
+		OpRPC:            n.OpRPC,
+		OpChainID:        n.OpChainID,
+		RelayURLs:        n.RelayURLs,
+	}, nil
+}
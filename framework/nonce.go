@@ -0,0 +1,136 @@
+package framework
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// maxNonceRetries bounds the resends of a transaction whose nonce was taken.
+const maxNonceRetries = 3
+
+// NonceSource returns the next nonce of an account including its pending
+// transactions. *ethclient.Client implements it.
+type NonceSource interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// NonceManager hands out the nonces of accounts shared by concurrent
+// senders, per chain. An account is synced from the node the first time it
+// is used and tracked locally afterwards.
+type NonceManager struct {
+	lock     sync.Mutex
+	accounts map[nonceKey]*nonceAccount
+}
+
+type nonceKey struct {
+	chainID string
+	addr    common.Address
+}
+
+type nonceAccount struct {
+	lock   sync.Mutex
+	synced bool
+	next   uint64
+	// released are nonces handed out but never sent, reused before next
+	// so that no gap is left.
+	released []uint64
+}
+
+var defaultNonceManager = NewNonceManager()
+
+// DefaultNonceManager returns the manager used by the frameworks built from a
+// config without Nonces, so that they never hand out the same nonce.
+func DefaultNonceManager() *NonceManager {
+	return defaultNonceManager
+}
+
+func NewNonceManager() *NonceManager {
+	return &NonceManager{accounts: map[nonceKey]*nonceAccount{}}
+}
+
+// Next reserves the next nonce of addr on the chain. The nonce must be given
+// back with Release if the transaction using it is not sent.
+func (m *NonceManager) Next(ctx context.Context, source NonceSource, chainID *big.Int, addr common.Address) (uint64, error) {
+	acct := m.account(chainID, addr)
+	acct.lock.Lock()
+	defer acct.lock.Unlock()
+
+	if !acct.synced {
+		if err := acct.sync(ctx, source, addr); err != nil {
+			return 0, err
+		}
+	}
+	if len(acct.released) > 0 {
+		nonce := acct.released[0]
+		acct.released = acct.released[1:]
+		return nonce, nil
+	}
+	nonce := acct.next
+	acct.next++
+	return nonce, nil
+}
+
+// Release gives back a nonce reserved with Next whose transaction was not
+// sent, to be handed out again before the following ones.
+func (m *NonceManager) Release(chainID *big.Int, addr common.Address, nonce uint64) {
+	acct := m.account(chainID, addr)
+	acct.lock.Lock()
+	defer acct.lock.Unlock()
+
+	if !acct.synced || nonce >= acct.next {
+		return
+	}
+	for _, released := range acct.released {
+		if released == nonce {
+			return
+		}
+	}
+	acct.released = append(acct.released, nonce)
+	sort.Slice(acct.released, func(i, j int) bool { return acct.released[i] < acct.released[j] })
+}
+
+// Resync reloads the next nonce of addr from source, e.g. after the node
+// reported a nonce as too low or transactions were dropped from the pool.
+func (m *NonceManager) Resync(ctx context.Context, source NonceSource, chainID *big.Int, addr common.Address) error {
+	acct := m.account(chainID, addr)
+	acct.lock.Lock()
+	defer acct.lock.Unlock()
+
+	return acct.sync(ctx, source, addr)
+}
+
+func (m *NonceManager) account(chainID *big.Int, addr common.Address) *nonceAccount {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	key := nonceKey{chainID: chainID.String(), addr: addr}
+	acct, ok := m.accounts[key]
+	if !ok {
+		acct = &nonceAccount{}
+		m.accounts[key] = acct
+	}
+	return acct
+}
+
+func (a *nonceAccount) sync(ctx context.Context, source NonceSource, addr common.Address) error {
+	pending, err := source.PendingNonceAt(ctx, addr)
+	if err != nil {
+		return wrapRPCError("eth_getTransactionCount", err)
+	}
+	a.synced = true
+	a.next = pending
+	a.released = nil
+	return nil
+}
+
+// isNonceTaken reports whether the node rejected a transaction because its
+// nonce was already used, by a mined or a pending transaction.
+func isNonceTaken(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "nonce too low") || strings.Contains(msg, "replacement transaction underpriced")
+}
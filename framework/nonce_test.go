@@ -0,0 +1,201 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	testChainID = big.NewInt(1)
+	testSender  = common.HexToAddress("0x00000000000000000000000000000000000000a1")
+)
+
+// fakeNonceSource reports pending as the next nonce of every account.
+type fakeNonceSource struct {
+	lock    sync.Mutex
+	pending uint64
+	err     error
+	calls   int
+}
+
+func (s *fakeNonceSource) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.calls++
+	return s.pending, s.err
+}
+
+// nonceStep is an operation on a NonceManager: Next expecting want, Release
+// of nonce, or Resync after the node moved to pending. A node step moves the
+// node without resyncing.
+type nonceStep struct {
+	op      string
+	nonce   uint64
+	pending uint64
+	want    uint64
+}
+
+func expectNext(want uint64) nonceStep    { return nonceStep{op: "next", want: want} }
+func releaseNonce(nonce uint64) nonceStep { return nonceStep{op: "release", nonce: nonce} }
+func resyncAt(pending uint64) nonceStep   { return nonceStep{op: "resync", pending: pending} }
+func nodeMoved(pending uint64) nonceStep  { return nonceStep{op: "node", pending: pending} }
+
+func TestNonceManager(t *testing.T) {
+	tests := []struct {
+		desc    string
+		pending uint64
+		steps   []nonceStep
+		syncs   int
+	}{
+		{
+			desc:    "synced once then tracked locally",
+			pending: 5,
+			steps:   []nonceStep{expectNext(5), expectNext(6), nodeMoved(20), expectNext(7)},
+			syncs:   1,
+		},
+		{
+			desc:    "released nonce reused first",
+			pending: 0,
+			steps:   []nonceStep{expectNext(0), expectNext(1), expectNext(2), releaseNonce(1), expectNext(1), expectNext(3)},
+			syncs:   1,
+		},
+		{
+			desc:    "released nonces reused lowest first",
+			pending: 0,
+			steps:   []nonceStep{expectNext(0), expectNext(1), expectNext(2), releaseNonce(2), releaseNonce(0), releaseNonce(2), expectNext(0), expectNext(2), expectNext(3)},
+			syncs:   1,
+		},
+		{
+			desc:    "unknown nonces not released",
+			pending: 3,
+			steps:   []nonceStep{releaseNonce(1), expectNext(3), releaseNonce(4), expectNext(4)},
+			syncs:   1,
+		},
+		{
+			desc:    "resync after nonce too low",
+			pending: 0,
+			steps:   []nonceStep{expectNext(0), expectNext(1), resyncAt(4), expectNext(4), expectNext(5)},
+			syncs:   2,
+		},
+		{
+			desc:    "resync drops released nonces",
+			pending: 0,
+			steps:   []nonceStep{expectNext(0), expectNext(1), releaseNonce(0), resyncAt(2), expectNext(2)},
+			syncs:   2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ctx := context.Background()
+			source := &fakeNonceSource{pending: tt.pending}
+			m := NewNonceManager()
+			for i, step := range tt.steps {
+				switch step.op {
+				case "next":
+					nonce, err := m.Next(ctx, source, testChainID, testSender)
+					if err != nil {
+						t.Fatalf("step %d: %v", i, err)
+					}
+					if nonce != step.want {
+						t.Fatalf("step %d: got nonce %d, want %d", i, nonce, step.want)
+					}
+				case "release":
+					m.Release(testChainID, testSender, step.nonce)
+				case "resync":
+					source.pending = step.pending
+					if err := m.Resync(ctx, source, testChainID, testSender); err != nil {
+						t.Fatalf("step %d: %v", i, err)
+					}
+				case "node":
+					source.pending = step.pending
+				}
+			}
+			if source.calls != tt.syncs {
+				t.Errorf("synced %d times, want %d", source.calls, tt.syncs)
+			}
+		})
+	}
+}
+
+func TestNonceManagerConcurrentNext(t *testing.T) {
+	const senders = 32
+
+	ctx := context.Background()
+	source := &fakeNonceSource{pending: 10}
+	m := NewNonceManager()
+
+	nonces := make([]uint64, senders)
+	var wg sync.WaitGroup
+	for i := range nonces {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			nonce, err := m.Next(ctx, source, testChainID, testSender)
+			if err != nil {
+				t.Error(err)
+			}
+			nonces[i] = nonce
+		}(i)
+	}
+	wg.Wait()
+
+	sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+	for i, nonce := range nonces {
+		if nonce != uint64(10+i) {
+			t.Fatalf("got nonces %v, want %d to %d without gaps or duplicates", nonces, 10, 10+senders-1)
+		}
+	}
+	if source.calls != 1 {
+		t.Errorf("synced %d times, want 1", source.calls)
+	}
+
+	// another chain is tracked on its own
+	nonce, err := m.Next(ctx, source, big.NewInt(2), testSender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nonce != 10 {
+		t.Errorf("got nonce %d on another chain, want 10", nonce)
+	}
+}
+
+func TestNonceManagerSyncError(t *testing.T) {
+	ctx := context.Background()
+	source := &fakeNonceSource{err: errors.New("connection refused")}
+	m := NewNonceManager()
+
+	if _, err := m.Next(ctx, source, testChainID, testSender); err == nil {
+		t.Fatal("got a nonce from a failing source")
+	}
+	source.err, source.pending = nil, 7
+	nonce, err := m.Next(ctx, source, testChainID, testSender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nonce != 7 {
+		t.Errorf("got nonce %d, want 7", nonce)
+	}
+}
+
+func TestIsNonceTaken(t *testing.T) {
+	tests := []struct {
+		err  string
+		want bool
+	}{
+		{err: "nonce too low: next nonce 4, tx nonce 2", want: true},
+		{err: "replacement transaction underpriced", want: true},
+		{err: "insufficient funds for gas * price + value", want: false},
+		{err: "nonce too high", want: false},
+	}
+	for _, tt := range tests {
+		if got := isNonceTaken(errors.New(tt.err)); got != tt.want {
+			t.Errorf("isNonceTaken(%q) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
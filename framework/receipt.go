@@ -0,0 +1,271 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const (
+	// receiptPollInterval is the delay between two receipt lookups.
+	receiptPollInterval = 100 * time.Millisecond
+	// poolCheckInterval is the delay between two checks that a pending
+	// transaction is still in the pool.
+	poolCheckInterval = time.Second
+	// maxPoolMisses is the number of pool checks in a row a transaction must
+	// be missing from before it is reported as dropped, as a node may briefly
+	// not know about a transaction it just got.
+	maxPoolMisses = 2
+)
+
+// pendingTx is a transaction sent by the framework, kept until it is mined.
+type pendingTx struct {
+	eth     *ethclient.Client
+	chainID *big.Int
+	sender  common.Address
+	nonce   uint64
+
+	// txn is the last signed version of the transaction, and hashes are the
+	// hashes of every version sent, any of which may be mined.
+	txn    *types.Transaction
+	hashes []common.Hash
+
+	// resign signs the transaction again with its fees raised by percent. It
+	// is nil for transactions whose fees cannot be bumped.
+	resign func(percent uint64) (*types.Transaction, error)
+}
+
+func newPendingTx(eth *ethclient.Client, chainID *big.Int, sender common.Address, txn *types.Transaction, hash common.Hash) *pendingTx {
+	return &pendingTx{
+		eth:     eth,
+		chainID: chainID,
+		sender:  sender,
+		nonce:   txn.Nonce(),
+		txn:     txn,
+		hashes:  []common.Hash{hash},
+	}
+}
+
+// hash returns the hash of the last version sent.
+func (p *pendingTx) hash() common.Hash {
+	return p.hashes[len(p.hashes)-1]
+}
+
+// resendable reports whether the transaction may be sent again. Confidential
+// requests are not, as the kettle would run their computation again.
+func (p *pendingTx) resendable() bool {
+	return p.txn.Type() != types.ConfidentialComputeRequestTxType
+}
+
+// waitSuccess waits for the receipt of p and returns a *RevertError with the
+// decoded reason if the transaction failed.
+func (f *Framework) waitSuccess(ctx context.Context, p *pendingTx, o *txOptions) (*types.Receipt, error) {
+	receipt, err := f.waitReceipt(ctx, p, o)
+	if err != nil {
+		return nil, err
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		return receipt, f.revertError(ctx, receipt)
+	}
+	return receipt, nil
+}
+
+// waitReceipt polls for the receipt of p until it has the confirmations set
+// by the options or the context is done. Without a deadline, the wait is
+// bounded by Config.ReceiptTimeout. A transaction that left the pool is sent
+// again if the options ask for rebroadcasts and fails with ErrTxDropped
+// otherwise, and one whose nonce was used by another transaction fails with
+// ErrTxReplaced.
+func (f *Framework) waitReceipt(ctx context.Context, p *pendingTx, o *txOptions) (*types.Receipt, error) {
+	if _, ok := ctx.Deadline(); !ok && f.config.ReceiptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.config.ReceiptTimeout)
+		defer cancel()
+	}
+
+	ticker := time.NewTicker(receiptPollInterval)
+	defer ticker.Stop()
+
+	var (
+		// transient errors are tolerated up to the attempts of the retry policy
+		failures  int
+		misses    int
+		sentAt    = time.Now()
+		checkedAt = time.Now()
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w %s: %w", ErrReceiptTimeout, p.hash().Hex(), ctx.Err())
+		case <-ticker.C:
+		}
+
+		receipt, err := f.confirmedReceipt(ctx, p, o.confirmations)
+		switch {
+		case err == nil && receipt != nil:
+			return receipt, nil
+		case err == nil:
+			// mined without enough confirmations yet
+			failures = 0
+			continue
+		case !errors.Is(err, ethereum.NotFound):
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("%w %s: %w", ErrReceiptTimeout, p.hash().Hex(), ctx.Err())
+			}
+			if failures++; failures < f.config.Retry.MaxAttempts && f.config.Retry.Retries(err) {
+				continue
+			}
+			return nil, err
+		}
+		failures = 0
+
+		if p.resendable() {
+			switch {
+			case o.bumpAfter > 0 && p.resign != nil && time.Since(sentAt) >= o.bumpAfter:
+				if err := f.bumpFees(ctx, p, o.bumpPercent); err != nil {
+					return nil, err
+				}
+				sentAt = time.Now()
+			case o.rebroadcast > 0 && time.Since(sentAt) >= o.rebroadcast:
+				if err := f.rebroadcast(ctx, p); err != nil {
+					return nil, err
+				}
+				sentAt = time.Now()
+			}
+		}
+
+		if time.Since(checkedAt) < poolCheckInterval {
+			continue
+		}
+		checkedAt = time.Now()
+
+		dropped, err := f.dropped(ctx, p)
+		if err != nil {
+			if errors.Is(err, ErrTxReplaced) || !f.config.Retry.Retries(err) && ctx.Err() == nil {
+				return nil, err
+			}
+			// checked again later
+			continue
+		}
+		if !dropped {
+			misses = 0
+			continue
+		}
+		if misses++; misses < maxPoolMisses {
+			continue
+		}
+		misses = 0
+
+		if o.rebroadcast == 0 || !p.resendable() {
+			// hand the nonce out again, as the transaction will never use it
+			if err := f.nonces.Resync(ctx, p.eth, p.chainID, p.sender); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: %s", ErrTxDropped, p.hash().Hex())
+		}
+		if err := f.rebroadcast(ctx, p); err != nil {
+			return nil, err
+		}
+		sentAt = time.Now()
+	}
+}
+
+// confirmedReceipt returns the receipt of the first version of p that was
+// mined, or nil if it has less than confirmations blocks on top of it. It
+// fails with ethereum.NotFound while no version is mined.
+func (f *Framework) confirmedReceipt(ctx context.Context, p *pendingTx, confirmations uint64) (*types.Receipt, error) {
+	receipt, err := f.minedReceipt(ctx, p)
+	if err != nil || confirmations == 0 {
+		return receipt, err
+	}
+
+	// the receipt is looked up again at each poll, so that a reorg moving
+	// the transaction restarts the count from its new block
+	head, err := p.eth.BlockNumber(ctx)
+	if err != nil {
+		return nil, wrapRPCError("eth_blockNumber", err)
+	}
+	if receipt.BlockNumber.Uint64()+confirmations > head {
+		return nil, nil
+	}
+	return receipt, nil
+}
+
+// minedReceipt returns the receipt of the first version of p that was mined.
+func (f *Framework) minedReceipt(ctx context.Context, p *pendingTx) (*types.Receipt, error) {
+	for _, hash := range p.hashes {
+		receipt, err := p.eth.TransactionReceipt(ctx, hash)
+		if err == nil {
+			return receipt, nil
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return nil, wrapRPCError("eth_getTransactionReceipt", err)
+		}
+	}
+	return nil, ethereum.NotFound
+}
+
+// dropped reports whether no version of p is known to the node anymore. It
+// fails with ErrTxReplaced if the nonce of p was used by another transaction.
+func (f *Framework) dropped(ctx context.Context, p *pendingTx) (bool, error) {
+	for _, hash := range p.hashes {
+		_, _, err := p.eth.TransactionByHash(ctx, hash)
+		if err == nil {
+			return false, nil
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return false, wrapRPCError("eth_getTransactionByHash", err)
+		}
+	}
+
+	nonce, err := p.eth.NonceAt(ctx, p.sender, nil)
+	if err != nil {
+		return false, wrapRPCError("eth_getTransactionCount", err)
+	}
+	if nonce <= p.nonce {
+		return true, nil
+	}
+
+	// one of the versions may have been mined since the last lookup
+	if _, err := f.minedReceipt(ctx, p); !errors.Is(err, ethereum.NotFound) {
+		return false, err
+	}
+	return false, fmt.Errorf("%w: %s by another transaction with nonce %d", ErrTxReplaced, p.hash().Hex(), p.nonce)
+}
+
+// rebroadcast sends the last version of p again. A nonce used in the meantime
+// is not an error, the next pool check tells whether p was mined or replaced.
+func (f *Framework) rebroadcast(ctx context.Context, p *pendingTx) error {
+	_, err := f.sendRawTransactionTo(ctx, p.eth.Client(), p.txn)
+	if err != nil && !isNonceTaken(err) {
+		return err
+	}
+	return nil
+}
+
+// bumpFees replaces p with a version paying fees raised by percent. A
+// replacement rejected as underpriced is retried with higher fees at the
+// next bump.
+func (f *Framework) bumpFees(ctx context.Context, p *pendingTx, percent uint64) error {
+	txn, err := p.resign(percent)
+	if err != nil {
+		return err
+	}
+	hash, err := f.sendRawTransactionTo(ctx, p.eth.Client(), txn)
+	if err != nil {
+		if isNonceTaken(err) {
+			return nil
+		}
+		return err
+	}
+	p.txn = txn
+	p.hashes = append(p.hashes, hash)
+	return nil
+}
@@ -0,0 +1,185 @@
+package framework
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/suave/sdk"
+)
+
+var (
+	// ErrDeploymentNotFound is returned when the manifest has no deployment for a name.
+	ErrDeploymentNotFound = errors.New("deployment not found")
+
+	errArtifactChanged = errors.New("artifact changed since the deployment")
+)
+
+// Deployment is a contract deployment recorded in the manifest.
+type Deployment struct {
+	Network      string         `json:"network"`
+	Name         string         `json:"name"`
+	Artifact     string         `json:"artifact,omitempty"`
+	ArtifactHash common.Hash    `json:"artifactHash"`
+	Address      common.Address `json:"address"`
+	// TxHash and Block are empty when a deterministic deployment found the contract already deployed.
+	TxHash common.Hash `json:"txHash"`
+	Block  uint64      `json:"block"`
+}
+
+// manifest is the JSON file layout, deployments by network and name.
+type manifest struct {
+	Deployments map[string]map[string]*Deployment `json:"deployments"`
+}
+
+// Registry persists deployments to a JSON manifest shared by processes
+// running from the same directory. Updates hold an advisory lock on the
+// manifest, so that concurrent writers do not lose each other's deployments.
+type Registry struct {
+	path string
+	lock sync.Mutex
+}
+
+func NewRegistry(path string) *Registry {
+	return &Registry{path: path}
+}
+
+// DefaultManifestPath returns deployments.json next to DefaultArtifactsDir.
+func DefaultManifestPath() string {
+	return filepath.Join(filepath.Dir(DefaultArtifactsDir()), "deployments.json")
+}
+
+// Record adds or replaces the deployment of d.Name on d.Network.
+func (r *Registry) Record(d *Deployment) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	unlock, err := lockFile(r.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	m, err := r.load()
+	if err != nil {
+		return err
+	}
+	if m.Deployments[d.Network] == nil {
+		m.Deployments[d.Network] = map[string]*Deployment{}
+	}
+	m.Deployments[d.Network][d.Name] = d
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(r.path, data)
+}
+
+// writeFileAtomic writes to a temporary file first so readers never see a
+// partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Lookup returns the deployment of name on network.
+func (r *Registry) Lookup(network, name string) (*Deployment, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	m, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	d, ok := m.Deployments[network][name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s on %s in %s", ErrDeploymentNotFound, name, network, r.path)
+	}
+	return d, nil
+}
+
+func (r *Registry) load() (*manifest, error) {
+	m := &manifest{Deployments: map[string]map[string]*Deployment{}}
+
+	data, err := os.ReadFile(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", r.path, err)
+	}
+	if m.Deployments == nil {
+		m.Deployments = map[string]map[string]*Deployment{}
+	}
+	return m, nil
+}
+
+// Hash identifies the creation code of the artifact.
+func (a *Artifact) Hash() common.Hash {
+	return crypto.Keccak256Hash(a.Code)
+}
+
+// contractName derives the deployment name from an artifact path,
+// e.g. "OpBuilder" for "optimism-builder.sol/OpBuilder.json".
+func contractName(path string) string {
+	if i := strings.LastIndex(path, ":"); i >= 0 {
+		return path[i+1:]
+	}
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// Registry returns the registry recording the deployments of the framework.
+func (f *Framework) Registry() *Registry {
+	return f.registry
+}
+
+// ContractByName returns the contract deployed as name on the network of the
+// config, bound to the funded account. It fails if the artifact the contract
+// was deployed from has changed since.
+func (f *Framework) ContractByName(name string) (*Contract, error) {
+	d, err := f.registry.Lookup(f.config.Network.Name, name)
+	if err != nil {
+		return nil, err
+	}
+	if d.Artifact == "" {
+		return nil, fmt.Errorf("%w: deployment %s has no artifact", ErrInvalidArtifact, name)
+	}
+
+	artifact, err := f.ReadArtifact(d.Artifact)
+	if err != nil {
+		return nil, err
+	}
+	if artifact.Hash() != d.ArtifactHash {
+		return nil, fmt.Errorf("%w: %s at %s", errArtifactChanged, name, d.Address.Hex())
+	}
+
+	contract := sdk.GetContract(d.Address, artifact.Abi, f.clt)
+	return &Contract{addr: d.Address, fr: f, abi: artifact.Abi, key: f.config.FundedAccount, Contract: contract}, nil
+}
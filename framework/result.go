@@ -0,0 +1,94 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var errNotSuaveTransaction = errors.New("not a suave transaction")
+
+// Result describes a confidential request executed by a kettle.
+type Result struct {
+	Receipt *types.Receipt
+
+	// Transaction is the SUAVE transaction the kettle submitted for the request.
+	Transaction *types.Transaction
+	// Request is the confidential compute record the transaction was built from.
+	Request *types.ConfidentialComputeRecord
+	// ConfidentialResult holds the bytes returned by the confidential execution,
+	// which are the calldata of the onchain callback.
+	ConfidentialResult []byte
+	// Kettle is the address of the kettle that executed the request.
+	Kettle common.Address
+
+	// Logs are the receipt logs decoded against the contract ABI.
+	Logs []*DecodedLog
+}
+
+// DecodedLog is a log with its fields decoded against an ABI event.
+// Name is empty when the log does not match any event of the ABI.
+type DecodedLog struct {
+	*types.Log
+
+	Name   string
+	Fields map[string]interface{}
+}
+
+// Send is like SendTransactionContext but also returns what the kettle
+// executed. On a revert, the result is returned together with the error.
+func (c *Contract) Send(ctx context.Context, method string, args []interface{}, confidentialBytes []byte, opts ...TxOption) (*Result, error) {
+	receipt, sendErr := c.SendTransactionContext(ctx, method, args, confidentialBytes, opts...)
+	if receipt == nil {
+		return nil, sendErr
+	}
+
+	result, err := c.fr.confidentialResult(ctx, receipt)
+	if err != nil {
+		return nil, err
+	}
+	result.Logs = decodeLogs(c.abi, receipt.Logs)
+	return result, sendErr
+}
+
+// confidentialResult fetches the SUAVE transaction of receipt.
+func (f *Framework) confidentialResult(ctx context.Context, receipt *types.Receipt) (*Result, error) {
+	txn, _, err := f.eth.TransactionByHash(ctx, receipt.TxHash)
+	if err != nil {
+		return nil, wrapRPCError("eth_getTransactionByHash", err)
+	}
+	inner, ok := types.CastTxInner[*types.SuaveTransaction](txn)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s has type %d", errNotSuaveTransaction, receipt.TxHash.Hex(), txn.Type())
+	}
+
+	return &Result{
+		Receipt:            receipt,
+		Transaction:        txn,
+		Request:            &inner.ConfidentialComputeRequest,
+		ConfidentialResult: inner.ConfidentialComputeResult,
+		Kettle:             inner.ConfidentialComputeRequest.KettleAddress,
+	}, nil
+}
+
+func decodeLogs(contractAbi *abi.ABI, logs []*types.Log) []*DecodedLog {
+	decoded := make([]*DecodedLog, 0, len(logs))
+	for _, log := range logs {
+		d := &DecodedLog{Log: log}
+		if len(log.Topics) != 0 {
+			if event, err := contractAbi.EventByID(log.Topics[0]); err == nil {
+				fields, err := unpackEventMap(event, log)
+				if err == nil {
+					d.Name = event.Name
+					d.Fields = fields
+				}
+			}
+		}
+		decoded = append(decoded, d)
+	}
+	return decoded
+}
@@ -0,0 +1,151 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrorClass is a class of transient errors, combined as a bit set.
+type ErrorClass int
+
+const (
+	// ErrorUnavailable is a failure to reach the node or to read its reply,
+	// e.g. a refused or reset connection or a timeout.
+	ErrorUnavailable ErrorClass = 1 << iota
+	// ErrorBusy is a node rejecting the request until later, e.g. rate
+	// limited or overloaded.
+	ErrorBusy
+	// ErrorNotSynced is a node missing the requested state yet, e.g.
+	// "header not found" for a block it did not import.
+	ErrorNotSynced
+
+	// AllTransientErrors are the classes retried by default.
+	AllTransientErrors = ErrorUnavailable | ErrorBusy | ErrorNotSynced
+)
+
+// RetryPolicy decides how requests to the node failing with a transient
+// error are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts of a request, 1 disables retries.
+	MaxAttempts int
+	// MinBackoff is the delay before the first retry, doubled after each
+	// attempt up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Jitter randomizes each delay by up to this fraction of it.
+	Jitter float64
+	// Classes are the retried classes of errors.
+	Classes ErrorClass
+}
+
+// DefaultRetryPolicy retries every transient error twice.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  200 * time.Millisecond,
+		MaxBackoff:  5 * time.Second,
+		Jitter:      0.2,
+		Classes:     AllTransientErrors,
+	}
+}
+
+// Do calls fn until it succeeds, fails with an error the policy does not
+// retry, runs out of attempts or ctx is done, and returns its last error.
+func (p RetryPolicy) Do(ctx context.Context, fn func() error) error {
+	return p.do(ctx, fn, p.Retries)
+}
+
+// do is like Do but retries the errors for which retry returns true.
+func (p RetryPolicy) do(ctx context.Context, fn func() error, retry func(err error) bool) error {
+	backoff := p.MinBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.MaxAttempts || !retry(err) {
+			return err
+		}
+
+		select {
+		case <-time.After(p.jitter(backoff)):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+		if backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+}
+
+// Retries reports whether err belongs to a class retried by the policy.
+func (p RetryPolicy) Retries(err error) bool {
+	return ClassifyError(err)&p.Classes != 0
+}
+
+func (p RetryPolicy) jitter(d time.Duration) time.Duration {
+	if p.Jitter <= 0 || d <= 0 {
+		return d
+	}
+	delta := float64(d) * p.Jitter
+	return d + time.Duration(delta*(2*rand.Float64()-1))
+}
+
+// ClassifyError returns the class of a transient error, zero for errors
+// that would fail again, such as a revert or a cancelled context.
+func ClassifyError(err error) ErrorClass {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return 0
+	}
+
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			return ErrorBusy
+		case http.StatusBadGateway, http.StatusGatewayTimeout:
+			return ErrorUnavailable
+		}
+		return 0
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		msg := strings.ToLower(rpcErr.Error())
+		switch {
+		case strings.Contains(msg, "header not found"), strings.Contains(msg, "unknown block"):
+			return ErrorNotSynced
+		case strings.Contains(msg, "busy"), strings.Contains(msg, "too many requests"), strings.Contains(msg, "rate limit"):
+			return ErrorBusy
+		}
+		return 0
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return ErrorUnavailable
+	}
+	return 0
+}
+
+// unsent reports whether a request failed with err before the node could
+// act on it, so that resending it cannot execute it twice.
+func unsent(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode == http.StatusServiceUnavailable
+	}
+	return ClassifyError(err) == ErrorBusy
+}
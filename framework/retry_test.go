@@ -0,0 +1,128 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// rpcError is an error returned by the node with a JSON-RPC error code.
+type rpcError struct {
+	code int
+	msg  string
+}
+
+func (e rpcError) Error() string  { return e.msg }
+func (e rpcError) ErrorCode() int { return e.code }
+
+// dialError is the error of a request whose connection failed with errno.
+func dialError(op string, errno syscall.Errno) error {
+	return &net.OpError{Op: op, Net: "tcp", Err: os.NewSyscallError(op, errno)}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		desc   string
+		err    error
+		class  ErrorClass
+		unsent bool
+	}{
+		{desc: "nil", err: nil},
+		{desc: "http 429", err: rpc.HTTPError{StatusCode: 429, Status: "429 Too Many Requests"}, class: ErrorBusy, unsent: true},
+		{desc: "http 503", err: rpc.HTTPError{StatusCode: 503, Status: "503 Service Unavailable"}, class: ErrorBusy, unsent: true},
+		{desc: "http 502", err: rpc.HTTPError{StatusCode: 502, Status: "502 Bad Gateway"}, class: ErrorUnavailable},
+		{desc: "http 504", err: rpc.HTTPError{StatusCode: 504, Status: "504 Gateway Timeout"}, class: ErrorUnavailable},
+		{desc: "http 400", err: rpc.HTTPError{StatusCode: 400, Status: "400 Bad Request"}},
+		{desc: "wrapped http 429", err: fmt.Errorf("eth_call: %w", rpc.HTTPError{StatusCode: 429}), class: ErrorBusy, unsent: true},
+		{desc: "connection refused", err: dialError("dial", syscall.ECONNREFUSED), class: ErrorUnavailable, unsent: true},
+		{desc: "connection reset", err: dialError("read", syscall.ECONNRESET), class: ErrorUnavailable},
+		{desc: "bare connection reset", err: syscall.ECONNRESET, class: ErrorUnavailable},
+		{desc: "broken pipe", err: syscall.EPIPE, class: ErrorUnavailable},
+		{desc: "unexpected eof", err: io.ErrUnexpectedEOF, class: ErrorUnavailable},
+		{desc: "dns failure", err: &net.DNSError{Err: "no such host", Name: "kettle"}, class: ErrorUnavailable, unsent: true},
+		{desc: "header not found", err: rpcError{code: -32000, msg: "header not found"}, class: ErrorNotSynced},
+		{desc: "unknown block", err: rpcError{code: -32000, msg: "Unknown block"}, class: ErrorNotSynced},
+		{desc: "rate limited", err: rpcError{code: -32005, msg: "rate limit exceeded"}, class: ErrorBusy, unsent: true},
+		{desc: "revert", err: rpcError{code: 3, msg: "execution reverted"}},
+		{desc: "nonce too low", err: rpcError{code: -32000, msg: "nonce too low"}},
+		{desc: "context canceled", err: context.Canceled},
+		{desc: "deadline exceeded", err: context.DeadlineExceeded},
+		{desc: "wrapped context canceled", err: fmt.Errorf("eth_call: %w", context.Canceled)},
+		{desc: "other", err: errors.New("invalid sender")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if class := ClassifyError(tt.err); class != tt.class {
+				t.Errorf("class is %d, want %d", class, tt.class)
+			}
+			if tt.err == nil {
+				return
+			}
+			if unsent := unsent(tt.err); unsent != tt.unsent {
+				t.Errorf("unsent is %v, want %v", unsent, tt.unsent)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	busy := rpcError{code: -32005, msg: "too many requests"}
+	revert := rpcError{code: 3, msg: "execution reverted"}
+	policy := RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond, Classes: AllTransientErrors}
+
+	tests := []struct {
+		desc     string
+		policy   RetryPolicy
+		errs     []error
+		attempts int
+		err      error
+	}{
+		{desc: "success", policy: policy, errs: []error{nil}, attempts: 1},
+		{desc: "transient then success", policy: policy, errs: []error{busy, busy, nil}, attempts: 3},
+		{desc: "out of attempts", policy: policy, errs: []error{busy, busy, busy, nil}, attempts: 3, err: busy},
+		{desc: "not retried", policy: policy, errs: []error{revert, nil}, attempts: 1, err: revert},
+		{desc: "class not enabled", policy: RetryPolicy{MaxAttempts: 3, Classes: ErrorNotSynced}, errs: []error{busy, nil}, attempts: 1, err: busy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			attempts := 0
+			err := tt.policy.Do(context.Background(), func() error {
+				err := tt.errs[attempts]
+				attempts++
+				return err
+			})
+			if err != tt.err {
+				t.Errorf("got error %v, want %v", err, tt.err)
+			}
+			if attempts != tt.attempts {
+				t.Errorf("made %d attempts, want %d", attempts, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDoCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := RetryPolicy{MaxAttempts: 10, MinBackoff: time.Hour, MaxBackoff: time.Hour, Classes: AllTransientErrors}
+
+	attempts := 0
+	err := policy.Do(ctx, func() error {
+		attempts++
+		cancel()
+		return syscall.ECONNRESET
+	})
+	if !errors.Is(err, syscall.ECONNRESET) {
+		t.Errorf("got error %v, want the last attempt's", err)
+	}
+	if attempts != 1 {
+		t.Errorf("made %d attempts after the context was cancelled, want 1", attempts)
+	}
+}
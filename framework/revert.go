@@ -0,0 +1,211 @@
+package framework
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	revertSelector = []byte{0x08, 0xc3, 0x79, 0xa0} // Error(string)
+	panicSelector  = []byte{0x4e, 0x48, 0x7b, 0x71} // Panic(uint256)
+
+	// suaveLibErrors are the errors raised by the Suave library precompile wrappers.
+	suaveLibErrors = `[{"inputs":[{"internalType":"address","name":"","type":"address"},{"internalType":"bytes","name":"","type":"bytes"}],"name":"PeekerReverted","type":"error"}]`
+)
+
+// errorRegistry holds the custom errors of the contracts known to a
+// framework by selector.
+type errorRegistry struct {
+	lock       sync.RWMutex
+	bySelector map[[4]byte]abi.Error
+}
+
+func newErrorRegistry() *errorRegistry {
+	r := &errorRegistry{bySelector: map[[4]byte]abi.Error{}}
+	suaveLib, err := abi.JSON(strings.NewReader(suaveLibErrors))
+	if err != nil {
+		panic(err)
+	}
+	r.register(&suaveLib)
+	return r
+}
+
+func (r *errorRegistry) register(contractAbi *abi.ABI) {
+	if contractAbi == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, e := range contractAbi.Errors {
+		var selector [4]byte
+		copy(selector[:], e.ID[:4])
+		r.bySelector[selector] = e
+	}
+}
+
+func (r *errorRegistry) lookup(data []byte) (abi.Error, bool) {
+	var selector [4]byte
+	copy(selector[:], data[:4])
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	e, ok := r.bySelector[selector]
+	return e, ok
+}
+
+// decode fills the decoded fields of the error from Data.
+func (e *RevertError) decode(errs *errorRegistry) {
+	e.Reason, e.Custom, e.Args, e.PanicCode = decodeRevertData(errs, e.Data)
+}
+
+// decodeRevertData decodes Error(string), Panic(uint256) and the custom errors
+// of errs.
+func decodeRevertData(errs *errorRegistry, data []byte) (reason string, custom *abi.Error, args []interface{}, panicCode *big.Int) {
+	if len(data) < 4 {
+		return "", nil, nil, nil
+	}
+
+	switch {
+	case bytes.Equal(data[:4], revertSelector):
+		if reason, err := abi.UnpackRevert(data); err == nil {
+			return reason, nil, nil, nil
+		}
+	case bytes.Equal(data[:4], panicSelector):
+		if len(data) == 4+32 {
+			code := new(big.Int).SetBytes(data[4:])
+			return fmt.Sprintf("panic: 0x%x", code), nil, nil, code
+		}
+	default:
+		if e, ok := errs.lookup(data); ok {
+			if args, err := e.Inputs.Unpack(data[4:]); err == nil {
+				return formatCustomError(errs, e, args), &e, args, nil
+			}
+		}
+	}
+	return "", nil, nil, nil
+}
+
+// formatCustomError renders a custom error like Name(arg1, arg2). Byte
+// arguments holding revert data or text, as PeekerReverted does, are decoded.
+func formatCustomError(errs *errorRegistry, e abi.Error, args []interface{}) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case []byte:
+			if nested, _, _, _ := decodeRevertData(errs, v); nested != "" {
+				parts[i] = nested
+			} else if utf8.Valid(v) && len(v) != 0 && isPrintable(v) {
+				parts[i] = fmt.Sprintf("%q", v)
+			} else {
+				parts[i] = hexutil.Encode(v)
+			}
+		case common.Address:
+			parts[i] = v.Hex()
+		default:
+			parts[i] = fmt.Sprint(v)
+		}
+	}
+	return fmt.Sprintf("%s(%s)", e.Name, strings.Join(parts, ", "))
+}
+
+func isPrintable(b []byte) bool {
+	for _, r := range string(b) {
+		if r < 0x20 && r != '\n' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// revertDataFromError extracts the revert data attached to a JSON-RPC error.
+func revertDataFromError(err error) ([]byte, bool) {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return nil, false
+	}
+	s, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return nil, false
+	}
+	data, decodeErr := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if decodeErr != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// callError turns the error of a call or send into a *RevertError if the
+// node returned revert data, and into an *RPCError otherwise.
+func (f *Framework) callError(method string, err error) error {
+	if data, ok := revertDataFromError(err); ok {
+		revertErr := &RevertError{Data: data}
+		revertErr.decode(f.errors)
+		return revertErr
+	}
+	return wrapRPCError(method, err)
+}
+
+// revertError builds the error for a failed receipt. The transaction is
+// replayed on top of the parent block to recover the revert data; if that
+// fails, the error has no reason.
+func (f *Framework) revertError(ctx context.Context, receipt *types.Receipt) *RevertError {
+	revertErr := &RevertError{TxHash: receipt.TxHash, Receipt: receipt}
+
+	msg, err := f.replayMsg(ctx, receipt.TxHash)
+	if err != nil {
+		return revertErr
+	}
+
+	var parent *big.Int
+	if receipt.BlockNumber != nil && receipt.BlockNumber.Sign() > 0 {
+		parent = new(big.Int).Sub(receipt.BlockNumber, big.NewInt(1))
+	}
+	if _, err := f.eth.CallContract(ctx, msg, parent); err != nil {
+		if data, ok := revertDataFromError(err); ok {
+			revertErr.Data = data
+			revertErr.decode(f.errors)
+		} else {
+			revertErr.Reason = err.Error()
+		}
+	}
+	return revertErr
+}
+
+// replayMsg rebuilds the call executed by the transaction hash. For SUAVE
+// transactions, this is the callback of the confidential request.
+func (f *Framework) replayMsg(ctx context.Context, hash common.Hash) (ethereum.CallMsg, error) {
+	txn, _, err := f.eth.TransactionByHash(ctx, hash)
+	if err != nil {
+		return ethereum.CallMsg{}, err
+	}
+	signer, err := f.Signer(ctx, SuaveChain)
+	if err != nil {
+		return ethereum.CallMsg{}, err
+	}
+	from, err := signer.Sender(txn)
+	if err != nil {
+		return ethereum.CallMsg{}, err
+	}
+	return ethereum.CallMsg{
+		From:     from,
+		To:       txn.To(),
+		Gas:      txn.Gas(),
+		GasPrice: txn.GasPrice(),
+		Value:    txn.Value(),
+		Data:     txn.Data(),
+	}, nil
+}
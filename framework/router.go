@@ -0,0 +1,415 @@
+package framework
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrorPolicy decides what a Router does when an event handler fails.
+type ErrorPolicy int
+
+const (
+	// StopOnError stops the router, which returns the error from Run.
+	StopOnError ErrorPolicy = iota
+	// SkipOnError drops the event and carries on.
+	SkipOnError
+	// RetryOnError calls the handler again with backoff and stops the
+	// router once the retries are exhausted.
+	RetryOnError
+)
+
+const (
+	defaultRetries      = 3
+	defaultRetryBackoff = time.Second
+)
+
+// Event is a log dispatched by a Router.
+type Event struct {
+	// Name is the name of the event in the contract ABI.
+	Name string
+	Log  types.Log
+
+	contract *Contract
+}
+
+// Decode decodes the event into out, a pointer to a struct laid out as for
+// EventDecoder.Decode.
+func (e *Event) Decode(out interface{}) error {
+	return e.contract.UnpackLog(out, e.Name, e.Log)
+}
+
+// EventHandler handles the events of a route.
+type EventHandler func(ctx context.Context, event *Event) error
+
+type routeOptions struct {
+	concurrency int
+	policy      ErrorPolicy
+	retries     int
+	backoff     time.Duration
+	revert      EventHandler
+	onError     func(event *Event, err error)
+}
+
+// RouteOption configures a route of a Router.
+type RouteOption func(*routeOptions)
+
+// WithConcurrency lets up to n events of the route be handled at once.
+// By default events are handled one at a time, in chain order.
+func WithConcurrency(n int) RouteOption {
+	return func(o *routeOptions) {
+		o.concurrency = n
+	}
+}
+
+// WithErrorPolicy sets what happens when the handler fails, StopOnError by default.
+func WithErrorPolicy(policy ErrorPolicy) RouteOption {
+	return func(o *routeOptions) {
+		o.policy = policy
+	}
+}
+
+// WithRetries sets the number of retries of RetryOnError and the delay before
+// the first one, doubled after each attempt.
+func WithRetries(n int, backoff time.Duration) RouteOption {
+	return func(o *routeOptions) {
+		o.retries = n
+		o.backoff = backoff
+	}
+}
+
+// WithRevertHandler registers h for the events of the route removed by a
+// reorg after being handled.
+func WithRevertHandler(h EventHandler) RouteOption {
+	return func(o *routeOptions) {
+		o.revert = h
+	}
+}
+
+// OnHandlerError registers fn to be called each time the handler fails,
+// before the error policy is applied.
+func OnHandlerError(fn func(event *Event, err error)) RouteOption {
+	return func(o *routeOptions) {
+		o.onError = fn
+	}
+}
+
+// RouteMetrics are the counters of a route.
+type RouteMetrics struct {
+	Contract common.Address
+	Event    string
+
+	Handled  uint64
+	Failed   uint64
+	Retried  uint64
+	Skipped  uint64
+	Reverted uint64
+	InFlight int
+	// Duration is the total time spent in the handler.
+	Duration time.Duration
+}
+
+type routeKey struct {
+	addr  common.Address
+	event common.Hash
+}
+
+type route struct {
+	contract *Contract
+	name     string
+	handler  EventHandler
+	opts     *routeOptions
+	sem      chan struct{}
+
+	lock    sync.Mutex
+	metrics RouteMetrics
+}
+
+// Router dispatches the logs of a subscriber to handlers registered per
+// contract and event name. It implements LogHandler, AsyncLogHandler and
+// LogRevertHandler, so that a subscriber cursor only moves past the events
+// whose handler returned.
+type Router struct {
+	lock     sync.Mutex
+	routes   map[routeKey]*route
+	order    []*route
+	wg       sync.WaitGroup
+	stopErr  error
+	stop     context.CancelFunc
+	unrouted uint64
+}
+
+func NewRouter() *Router {
+	return &Router{routes: map[routeKey]*route{}}
+}
+
+// Handle registers h for the events named eventName emitted by contract.
+func (r *Router) Handle(contract *Contract, eventName string, h EventHandler, opts ...RouteOption) error {
+	event, ok := contract.abi.Events[eventName]
+	if !ok {
+		return &ABIError{Method: eventName, Err: fmt.Errorf("event not found")}
+	}
+
+	o := &routeOptions{
+		concurrency: 1,
+		retries:     defaultRetries,
+		backoff:     defaultRetryBackoff,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.concurrency < 1 {
+		o.concurrency = 1
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	key := routeKey{addr: contract.addr, event: event.ID}
+	if _, ok := r.routes[key]; ok {
+		return fmt.Errorf("event %s of %s already has a handler", eventName, contract.addr.Hex())
+	}
+	rt := &route{
+		contract: contract,
+		name:     eventName,
+		handler:  h,
+		opts:     o,
+		sem:      make(chan struct{}, o.concurrency),
+		metrics:  RouteMetrics{Contract: contract.addr, Event: eventName},
+	}
+	r.routes[key] = rt
+	r.order = append(r.order, rt)
+	return nil
+}
+
+// Run subscribes to the logs of the registered routes and dispatches them
+// until ctx is done or a handler stops the router. It waits for the handlers
+// in flight before returning.
+func (r *Router) Run(ctx context.Context, opts ...SubscriberOption) error {
+	r.lock.Lock()
+	if len(r.order) == 0 {
+		r.lock.Unlock()
+		return errors.New("router has no routes")
+	}
+	fr := r.order[0].contract.fr
+	query := ethereum.FilterQuery{Topics: [][]common.Hash{nil}}
+	addrs := map[common.Address]bool{}
+	topics := map[common.Hash]bool{}
+	for key := range r.routes {
+		if !addrs[key.addr] {
+			addrs[key.addr] = true
+			query.Addresses = append(query.Addresses, key.addr)
+		}
+		if !topics[key.event] {
+			topics[key.event] = true
+			query.Topics[0] = append(query.Topics[0], key.event)
+		}
+	}
+	sortFilterQuery(&query)
+
+	ctx, cancel := context.WithCancel(ctx)
+	r.stop = cancel
+	r.lock.Unlock()
+	defer cancel()
+
+	err := fr.NewSubscriber(query, opts...).Run(ctx, r)
+	r.wg.Wait()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.stopErr != nil {
+		return r.stopErr
+	}
+	return err
+}
+
+// sortFilterQuery orders the query so that its derived cursor key is stable.
+func sortFilterQuery(query *ethereum.FilterQuery) {
+	sort.Slice(query.Addresses, func(i, j int) bool {
+		return bytes.Compare(query.Addresses[i][:], query.Addresses[j][:]) < 0
+	})
+	for _, topics := range query.Topics {
+		sort.Slice(topics, func(i, j int) bool {
+			return bytes.Compare(topics[i][:], topics[j][:]) < 0
+		})
+	}
+}
+
+// HandleLog dispatches log to the handler of its route. Handlers of routes
+// with a concurrency above one run in the background.
+func (r *Router) HandleLog(ctx context.Context, log types.Log) error {
+	return r.HandleLogAsync(ctx, log, func() error { return nil })
+}
+
+// HandleLogAsync is like HandleLog but calls done once the handler of log
+// succeeded or the event was skipped. The router stops if done fails.
+func (r *Router) HandleLogAsync(ctx context.Context, log types.Log, done func() error) error {
+	rt, err := r.route(log)
+	if rt == nil {
+		if err != nil {
+			return err
+		}
+		return done()
+	}
+
+	select {
+	case rt.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	event := &Event{Name: rt.name, Log: log, contract: rt.contract}
+	if rt.opts.concurrency == 1 {
+		defer func() { <-rt.sem }()
+		if err := r.dispatch(ctx, rt, event); err != nil {
+			return err
+		}
+		return done()
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer func() { <-rt.sem }()
+		err := r.dispatch(ctx, rt, event)
+		if err == nil {
+			err = done()
+		}
+		if err != nil {
+			r.fail(err)
+		}
+	}()
+	return nil
+}
+
+// RevertLog passes a log removed by a reorg to the revert handler of its route.
+func (r *Router) RevertLog(ctx context.Context, log types.Log) error {
+	rt, err := r.route(log)
+	if rt == nil {
+		return err
+	}
+	rt.lock.Lock()
+	rt.metrics.Reverted++
+	rt.lock.Unlock()
+
+	if rt.opts.revert == nil {
+		return nil
+	}
+	return rt.opts.revert(ctx, &Event{Name: rt.name, Log: log, contract: rt.contract})
+}
+
+// Metrics returns the counters of the routes in registration order.
+func (r *Router) Metrics() []RouteMetrics {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	metrics := make([]RouteMetrics, len(r.order))
+	for i, rt := range r.order {
+		rt.lock.Lock()
+		metrics[i] = rt.metrics
+		rt.lock.Unlock()
+	}
+	return metrics
+}
+
+// Unrouted returns the number of logs that matched no route.
+func (r *Router) Unrouted() uint64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.unrouted
+}
+
+// route returns the route of log, or an error if the router was stopped.
+func (r *Router) route(log types.Log) (*route, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.stopErr != nil {
+		return nil, r.stopErr
+	}
+	if len(log.Topics) == 0 {
+		r.unrouted++
+		return nil, nil
+	}
+	rt, ok := r.routes[routeKey{addr: log.Address, event: log.Topics[0]}]
+	if !ok {
+		r.unrouted++
+		return nil, nil
+	}
+	return rt, nil
+}
+
+// dispatch runs the handler of rt on event and applies the error policy.
+func (r *Router) dispatch(ctx context.Context, rt *route, event *Event) error {
+	backoff := rt.opts.backoff
+	for attempt := 0; ; attempt++ {
+		rt.lock.Lock()
+		rt.metrics.InFlight++
+		rt.lock.Unlock()
+
+		start := time.Now()
+		err := rt.handler(ctx, event)
+
+		rt.lock.Lock()
+		rt.metrics.InFlight--
+		rt.metrics.Duration += time.Since(start)
+		if err == nil {
+			rt.metrics.Handled++
+		} else {
+			rt.metrics.Failed++
+		}
+		rt.lock.Unlock()
+
+		if err == nil {
+			return nil
+		}
+		err = fmt.Errorf("%s handler: %w", rt.name, err)
+		if rt.opts.onError != nil {
+			rt.opts.onError(event, err)
+		}
+
+		switch rt.opts.policy {
+		case SkipOnError:
+			rt.lock.Lock()
+			rt.metrics.Skipped++
+			rt.lock.Unlock()
+			return nil
+		case RetryOnError:
+			if attempt >= rt.opts.retries {
+				return err
+			}
+		default:
+			return err
+		}
+
+		rt.lock.Lock()
+		rt.metrics.Retried++
+		rt.lock.Unlock()
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// fail stops the router with err, unless it was stopped already.
+func (r *Router) fail(err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.stopErr == nil {
+		r.stopErr = err
+		if r.stop != nil {
+			r.stop()
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const routerABI = `[{"type": "event", "name": "Test", "inputs": [{"name": "value", "type": "uint256", "indexed": false}]}]`
+
+var errHandler = errors.New("handler failed")
+
+// newTestRouter returns a router with one route for the Test event of
+// testContract, read through fr.
+func newTestRouter(t *testing.T, fr *Framework, h EventHandler, opts ...RouteOption) *Router {
+	t.Helper()
+	contractAbi, err := abi.JSON(strings.NewReader(routerABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract := &Contract{addr: testContract, abi: &contractAbi, fr: fr}
+	r := NewRouter()
+	if err := r.Handle(contract, "Test", h, opts...); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// testLog returns log index of a block of testContract.
+func testLog(block uint64, index uint) types.Log {
+	return types.Log{
+		Address:     testContract,
+		Topics:      []common.Hash{testTopic},
+		BlockNumber: block,
+		BlockHash:   common.BigToHash(common.Big1),
+		Index:       index,
+	}
+}
+
+func TestRouterErrorPolicy(t *testing.T) {
+	tests := []struct {
+		desc        string
+		opts        []RouteOption
+		failures    int
+		err         bool
+		done        bool
+		handled     uint64
+		failed      uint64
+		retried     uint64
+		skipped     uint64
+		onErrorRuns int
+	}{
+		{
+			desc: "success",
+			done: true, handled: 1,
+		},
+		{
+			desc:     "stop",
+			failures: 1,
+			err:      true, failed: 1, onErrorRuns: 1,
+		},
+		{
+			desc:     "skip",
+			opts:     []RouteOption{WithErrorPolicy(SkipOnError)},
+			failures: 1,
+			done:     true, failed: 1, skipped: 1, onErrorRuns: 1,
+		},
+		{
+			desc:     "retry until success",
+			opts:     []RouteOption{WithErrorPolicy(RetryOnError), WithRetries(3, time.Millisecond)},
+			failures: 2,
+			done:     true, handled: 1, failed: 2, retried: 2, onErrorRuns: 2,
+		},
+		{
+			desc:     "retries exhausted",
+			opts:     []RouteOption{WithErrorPolicy(RetryOnError), WithRetries(2, time.Millisecond)},
+			failures: 5,
+			err:      true, failed: 3, retried: 2, onErrorRuns: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			calls, onErrorRuns := 0, 0
+			handler := func(ctx context.Context, event *Event) error {
+				calls++
+				if calls <= tt.failures {
+					return errHandler
+				}
+				return nil
+			}
+			opts := append(tt.opts, OnHandlerError(func(event *Event, err error) {
+				if !errors.Is(err, errHandler) {
+					t.Errorf("got handler error %v", err)
+				}
+				onErrorRuns++
+			}))
+			r := newTestRouter(t, newTestFramework(""), handler, opts...)
+
+			done := false
+			err := r.HandleLogAsync(context.Background(), testLog(1, 0), func() error {
+				done = true
+				return nil
+			})
+			if (err != nil) != tt.err || err != nil && !errors.Is(err, errHandler) {
+				t.Errorf("got error %v, want error %v", err, tt.err)
+			}
+			if done != tt.done {
+				t.Errorf("done is %v, want %v", done, tt.done)
+			}
+			if onErrorRuns != tt.onErrorRuns {
+				t.Errorf("error callback ran %d times, want %d", onErrorRuns, tt.onErrorRuns)
+			}
+			m := r.Metrics()[0]
+			if m.Handled != tt.handled || m.Failed != tt.failed || m.Retried != tt.retried || m.Skipped != tt.skipped || m.InFlight != 0 {
+				t.Errorf("got metrics %+v", m)
+			}
+		})
+	}
+}
+
+func TestRouterConcurrency(t *testing.T) {
+	const (
+		concurrency = 3
+		logs        = 8
+	)
+
+	var (
+		lock             sync.Mutex
+		inFlight, most   int
+		started, release = make(chan uint, logs), make(chan struct{})
+	)
+	handler := func(ctx context.Context, event *Event) error {
+		lock.Lock()
+		if inFlight++; inFlight > most {
+			most = inFlight
+		}
+		lock.Unlock()
+		started <- event.Log.Index
+
+		<-release
+		lock.Lock()
+		inFlight--
+		lock.Unlock()
+		return nil
+	}
+	r := newTestRouter(t, newTestFramework(""), handler, WithConcurrency(concurrency))
+
+	var acked sync.WaitGroup
+	acked.Add(logs)
+	go func() {
+		for i := uint(0); i < logs; i++ {
+			if err := r.HandleLogAsync(context.Background(), testLog(1, i), func() error {
+				acked.Done()
+				return nil
+			}); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+
+	for i := 0; i < concurrency; i++ {
+		<-started
+	}
+	select {
+	case index := <-started:
+		t.Fatalf("log %d started with %d logs in flight", index, concurrency)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if m := r.Metrics()[0]; m.InFlight != concurrency {
+		t.Errorf("%d logs in flight, want %d", m.InFlight, concurrency)
+	}
+
+	close(release)
+	acked.Wait()
+	r.wg.Wait()
+	if most != concurrency {
+		t.Errorf("up to %d logs in flight, want %d", most, concurrency)
+	}
+	if m := r.Metrics()[0]; m.Handled != logs {
+		t.Errorf("handled %d logs, want %d", m.Handled, logs)
+	}
+}
+
+// recordingCursorStore keeps the saved cursors and checks each of them with
+// check when it is saved.
+type recordingCursorStore struct {
+	lock   sync.Mutex
+	saves  []Cursor
+	check  func(cursor Cursor) error
+	errors []error
+}
+
+func (s *recordingCursorStore) Load(key string) (*Cursor, error) {
+	return nil, nil
+}
+
+func (s *recordingCursorStore) Save(key string, cursor Cursor) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if n := len(s.saves); n > 0 && cursor.Index <= s.saves[n-1].Index {
+		s.errors = append(s.errors, fmt.Errorf("cursor moved back from %d to %d", s.saves[n-1].Index, cursor.Index))
+	}
+	if err := s.check(cursor); err != nil {
+		s.errors = append(s.errors, err)
+	}
+	s.saves = append(s.saves, cursor)
+	return nil
+}
+
+func (s *recordingCursorStore) last() *Cursor {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.saves) == 0 {
+		return nil
+	}
+	return &s.saves[len(s.saves)-1]
+}
+
+// outcome finishes the handler of a log, failing it with fail.
+type outcome struct {
+	index uint
+	fail  bool
+}
+
+func TestRouterCursorAcks(t *testing.T) {
+	const logs = 4
+
+	tests := []struct {
+		desc     string
+		policy   ErrorPolicy
+		outcomes []outcome
+		// cursor is the index of the last saved cursor, -1 for none
+		cursor int
+		err    bool
+	}{
+		{
+			desc:     "in order",
+			outcomes: []outcome{{0, false}, {1, false}, {2, false}, {3, false}},
+			cursor:   3,
+		},
+		{
+			desc:     "out of order",
+			outcomes: []outcome{{2, false}, {3, false}, {0, false}, {1, false}},
+			cursor:   3,
+		},
+		{
+			desc:     "unfinished first event",
+			outcomes: []outcome{{3, false}, {2, false}, {1, false}, {0, true}},
+			cursor:   -1,
+			err:      true,
+		},
+		{
+			desc:     "failed event in the middle",
+			outcomes: []outcome{{0, false}, {3, false}, {2, false}, {1, true}},
+			cursor:   0,
+			err:      true,
+		},
+		{
+			desc:     "skipped failure",
+			policy:   SkipOnError,
+			outcomes: []outcome{{1, true}, {3, false}, {0, false}, {2, false}},
+			cursor:   3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			chain, url := newFakeChain(t)
+			chain.AddBlock(logs)
+
+			var (
+				lock     sync.Mutex
+				acked    = map[uint]bool{}
+				started  = make(chan uint, logs)
+				returned = make(chan uint, logs)
+				results  = map[uint]chan bool{}
+			)
+			for i := uint(0); i < logs; i++ {
+				results[i] = make(chan bool, 1)
+			}
+			handler := func(ctx context.Context, event *Event) error {
+				index := event.Log.Index
+				started <- index
+				defer func() { returned <- index }()
+
+				var fail bool
+				select {
+				case fail = <-results[index]:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				if !fail || tt.policy == SkipOnError {
+					lock.Lock()
+					acked[index] = true
+					lock.Unlock()
+				}
+				if fail {
+					return errHandler
+				}
+				return nil
+			}
+			store := &recordingCursorStore{check: func(cursor Cursor) error {
+				lock.Lock()
+				defer lock.Unlock()
+				for i := uint(0); i <= cursor.Index; i++ {
+					if !acked[i] {
+						return fmt.Errorf("cursor saved at %d before log %d was handled", cursor.Index, i)
+					}
+				}
+				return nil
+			}}
+
+			fr := newTestFramework(url)
+			r := newTestRouter(t, fr, handler, WithConcurrency(logs), WithErrorPolicy(tt.policy))
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			errc := make(chan error, 1)
+			go func() {
+				errc <- r.Run(ctx, WithCursor(store, "test"), WithStartBlock(1), WithPollInterval(10*time.Millisecond))
+			}()
+
+			// every log is in flight before any handler returns
+			for i := 0; i < logs; i++ {
+				select {
+				case <-started:
+				case <-time.After(5 * time.Second):
+					t.Fatalf("%d of %d logs in flight", i, logs)
+				}
+			}
+			for _, o := range tt.outcomes {
+				results[o.index] <- o.fail
+				<-returned
+			}
+
+			if !tt.err {
+				deadline := time.Now().Add(5 * time.Second)
+				for last := store.last(); last == nil || int(last.Index) != tt.cursor; last = store.last() {
+					if time.Now().After(deadline) {
+						t.Fatalf("cursor at %v, want log %d", last, tt.cursor)
+					}
+					time.Sleep(5 * time.Millisecond)
+				}
+				cancel()
+			}
+			err := <-errc
+			if tt.err != errors.Is(err, errHandler) {
+				t.Errorf("got error %v, want handler error %v", err, tt.err)
+			}
+
+			for _, err := range store.errors {
+				t.Error(err)
+			}
+			last := store.last()
+			if tt.cursor < 0 && last != nil {
+				t.Errorf("cursor saved at %d, want none", last.Index)
+			}
+			if tt.cursor >= 0 && (last == nil || int(last.Index) != tt.cursor) {
+				t.Errorf("cursor saved at %v, want log %d", last, tt.cursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package framework
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ChainSigner signs transactions for one chain without a connection.
+type ChainSigner struct {
+	chainID *big.Int
+	signer  types.Signer
+}
+
+// NewChainSigner returns a signer of legacy, access list, dynamic fee and blob
+// transactions for chainID.
+func NewChainSigner(chainID *big.Int) *ChainSigner {
+	return &ChainSigner{chainID: chainID, signer: types.NewCancunSigner(chainID)}
+}
+
+// NewSuaveChainSigner returns a signer for the SUAVE chain with id chainID,
+// which also signs confidential compute requests.
+func NewSuaveChainSigner(chainID *big.Int) *ChainSigner {
+	return &ChainSigner{chainID: chainID, signer: types.NewSuaveSigner(chainID)}
+}
+
+// ChainID returns the chain id transactions are signed for.
+func (s *ChainSigner) ChainID() *big.Int {
+	return s.chainID
+}
+
+// Signer returns the go-ethereum signer of the chain.
+func (s *ChainSigner) Signer() types.Signer {
+	return s.signer
+}
+
+// SignTx signs tx with key. The chain id of typed transactions is set if
+// missing.
+func (s *ChainSigner) SignTx(key *PrivKey, tx types.TxData) (*types.Transaction, error) {
+	switch tx := tx.(type) {
+	case *types.AccessListTx:
+		if tx.ChainID == nil {
+			tx.ChainID = s.chainID
+		}
+	case *types.DynamicFeeTx:
+		if tx.ChainID == nil {
+			tx.ChainID = s.chainID
+		}
+	}
+	return types.SignTx(types.NewTx(tx), s.signer, key.Priv)
+}
+
+// Sender returns the address that signed tx.
+func (s *ChainSigner) Sender(tx *types.Transaction) (common.Address, error) {
+	return types.Sender(s.signer, tx)
+}
+
+// Signer returns the signer of chain. Its chain id is taken from the network
+// profile or, if the profile has none, queried once from the chain.
+func (f *Framework) Signer(ctx context.Context, chain Chain) (*ChainSigner, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if signer, ok := f.signers[chain]; ok {
+		return signer, nil
+	}
+
+	var chainID *big.Int
+	if id := f.config.Network.ChainID(chain); id != 0 {
+		chainID = new(big.Int).SetUint64(id)
+	} else {
+		var err error
+		if chainID, err = f.queryChainID(ctx, chain); err != nil {
+			return nil, err
+		}
+	}
+
+	signer := NewChainSigner(chainID)
+	if chain == SuaveChain {
+		signer = NewSuaveChainSigner(chainID)
+	}
+	f.signers[chain] = signer
+	return signer, nil
+}
+
+func (f *Framework) queryChainID(ctx context.Context, chain Chain) (*big.Int, error) {
+	client, err := f.Client(ctx, chain)
+	if err != nil {
+		return nil, err
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, wrapRPCError("eth_chainId", err)
+	}
+	return chainID, nil
+}
@@ -0,0 +1,703 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	defaultMinBackoff = 500 * time.Millisecond
+	defaultMaxBackoff = 30 * time.Second
+
+	defaultPollInterval = time.Second
+
+	// seenBlocks is how many blocks before the backfill start delivered logs
+	// are remembered, to drop duplicates still queued on the subscription.
+	seenBlocks = 64
+)
+
+// LogHandler processes the logs delivered by a Subscriber.
+type LogHandler interface {
+	HandleLog(ctx context.Context, log types.Log) error
+}
+
+// LogRevertHandler is implemented by handlers that need to know when a log
+// they handled is removed from the chain by a reorg.
+type LogRevertHandler interface {
+	RevertLog(ctx context.Context, log types.Log) error
+}
+
+// AsyncLogHandler is implemented by handlers that process logs in the
+// background. HandleLogAsync must call done once log is handled; the cursor
+// of the subscriber only moves past logs whose done was called, so that a
+// log still in flight or failed is delivered again on resume. done returns
+// the error of saving the cursor.
+type AsyncLogHandler interface {
+	HandleLogAsync(ctx context.Context, log types.Log, done func() error) error
+}
+
+// LogHandlerFunc adapts a function to a LogHandler.
+type LogHandlerFunc func(ctx context.Context, log types.Log) error
+
+func (f LogHandlerFunc) HandleLog(ctx context.Context, log types.Log) error {
+	return f(ctx, log)
+}
+
+type subscriberOptions struct {
+	startBlock    *uint64
+	confirmations *uint64
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+	onConnError   func(err error, retryIn time.Duration)
+	cursors       CursorStore
+	cursorKey     string
+	endpoint      string
+	pollInterval  time.Duration
+}
+
+// SubscriberOption configures a Subscriber.
+type SubscriberOption func(*subscriberOptions)
+
+// WithStartBlock backfills the logs from block n on the first connection.
+// By default only logs emitted after the first connection are delivered.
+func WithStartBlock(n uint64) SubscriberOption {
+	return func(o *subscriberOptions) {
+		o.startBlock = &n
+	}
+}
+
+// WithConfirmations delays the delivery of logs until n blocks are built on
+// top of them, overriding Config.Confirmations. Logs removed before then are
+// never delivered.
+func WithConfirmations(n uint64) SubscriberOption {
+	return func(o *subscriberOptions) {
+		o.confirmations = &n
+	}
+}
+
+// WithBackoff sets the delay before reconnecting, doubled after each failed
+// attempt from min up to max.
+func WithBackoff(min, max time.Duration) SubscriberOption {
+	return func(o *subscriberOptions) {
+		o.minBackoff = min
+		o.maxBackoff = max
+	}
+}
+
+// WithEndpoint sets the RPC endpoint the logs are read from, the websocket
+// endpoint of the network or, if it has none, the kettle RPC by default.
+func WithEndpoint(url string) SubscriberOption {
+	return func(o *subscriberOptions) {
+		o.endpoint = url
+	}
+}
+
+// WithPollInterval sets how often an endpoint without subscriptions, such as
+// an HTTP one, is polled for new logs.
+func WithPollInterval(d time.Duration) SubscriberOption {
+	return func(o *subscriberOptions) {
+		o.pollInterval = d
+	}
+}
+
+// OnConnectionError registers fn to be called when the subscription fails,
+// before reconnecting.
+func OnConnectionError(fn func(err error, retryIn time.Duration)) SubscriberOption {
+	return func(o *subscriberOptions) {
+		o.onConnError = fn
+	}
+}
+
+// Subscriber streams logs matching a filter from the websocket endpoint of
+// the network. It reconnects with backoff when the subscription fails and
+// backfills the logs missed in between with eth_getLogs, so that each log
+// is delivered once. Endpoints without subscriptions, such as HTTP ones, are
+// polled with eth_getLogs over the new blocks instead.
+//
+// Logs removed by a reorg after their delivery are passed to the RevertLog
+// method of handlers implementing LogRevertHandler. When polling, the blocks
+// of the logs delivered within the last 64 blocks are checked against the
+// canonical chain whenever the head seen by the previous poll is replaced.
+type Subscriber struct {
+	fr            *Framework
+	query         ethereum.FilterQuery
+	opts          *subscriberOptions
+	confirmations uint64
+
+	// cursorKey is the key of the cursor in opts.cursors, resumed once
+	cursorKey string
+	resumed   bool
+
+	// next is the first block not fully delivered yet, nil before connecting.
+	// floor is the first block the subscriber was asked to deliver.
+	next    *uint64
+	floor   uint64
+	seen    map[logKey]uint64
+	pending map[logKey]types.Log
+
+	// delivered are the logs of seen, kept to retract them when polling.
+	// polledHead and polledHash are the head of the last poll.
+	delivered  map[logKey]types.Log
+	polledHead uint64
+	polledHash common.Hash
+
+	// acks are the delivered logs in delivery order, until they and the
+	// ones before them are handled.
+	ackLock sync.Mutex
+	acks    []*logAck
+}
+
+type logAck struct {
+	log  types.Log
+	done bool
+}
+
+type logKey struct {
+	block common.Hash
+	index uint
+}
+
+// NewSubscriber returns a subscriber for the logs matching query. The block
+// range of query is ignored, see WithStartBlock.
+func (f *Framework) NewSubscriber(query ethereum.FilterQuery, opts ...SubscriberOption) *Subscriber {
+	o := &subscriberOptions{
+		minBackoff:   defaultMinBackoff,
+		maxBackoff:   defaultMaxBackoff,
+		pollInterval: defaultPollInterval,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	query.FromBlock, query.ToBlock, query.BlockHash = nil, nil, nil
+
+	s := &Subscriber{
+		fr:            f,
+		query:         query,
+		opts:          o,
+		confirmations: f.config.Confirmations,
+		cursorKey:     o.cursorKey,
+		next:          o.startBlock,
+		seen:          map[logKey]uint64{},
+		pending:       map[logKey]types.Log{},
+		delivered:     map[logKey]types.Log{},
+	}
+	if o.confirmations != nil {
+		s.confirmations = *o.confirmations
+	}
+	if o.startBlock != nil {
+		s.floor = *o.startBlock
+	}
+	if s.cursorKey == "" {
+		var addrs, topics []string
+		for _, addr := range query.Addresses {
+			addrs = append(addrs, addr.Hex())
+		}
+		if len(query.Topics) > 0 {
+			for _, topic := range query.Topics[0] {
+				topics = append(topics, topic.Hex())
+			}
+		}
+		s.cursorKey = cursorKey(f.config.Network.Name, addrs, topics)
+	}
+	return s
+}
+
+// NewSubscriber returns a subscriber for the logs of the contract. With
+// eventNames, only the logs of these events are delivered.
+func (c *Contract) NewSubscriber(eventNames []string, opts ...SubscriberOption) (*Subscriber, error) {
+	query := ethereum.FilterQuery{Addresses: []common.Address{c.addr}}
+	if len(eventNames) != 0 {
+		ids := make([]common.Hash, len(eventNames))
+		for i, name := range eventNames {
+			event, ok := c.abi.Events[name]
+			if !ok {
+				return nil, &ABIError{Method: name, Err: fmt.Errorf("event not found")}
+			}
+			ids[i] = event.ID
+		}
+		query.Topics = [][]common.Hash{ids}
+	}
+	s := c.fr.NewSubscriber(query, opts...)
+	if s.opts.cursorKey == "" {
+		s.cursorKey = cursorKey(c.fr.config.Network.Name, []string{c.addr.Hex()}, eventNames)
+	}
+	return s, nil
+}
+
+// cursorKey identifies the logs of a subscriber, e.g. "local/0x12..34/Event".
+func cursorKey(network string, addrs, events []string) string {
+	key := network + "/" + strings.Join(addrs, ",") + "/"
+	if len(events) == 0 {
+		return key + "*"
+	}
+	return key + strings.Join(events, ",")
+}
+
+// Run delivers logs to h until ctx is done or h returns an error, which Run
+// returns. Connection failures are retried.
+func (s *Subscriber) Run(ctx context.Context, h LogHandler) error {
+	if err := s.resume(); err != nil {
+		return err
+	}
+
+	backoff := s.opts.minBackoff
+	for {
+		connected, err := s.session(ctx, h)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var handlerErr *handlerError
+		if errors.As(err, &handlerErr) {
+			return handlerErr.err
+		}
+
+		if connected {
+			backoff = s.opts.minBackoff
+		}
+		if s.opts.onConnError != nil {
+			s.opts.onConnError(err, backoff)
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if backoff *= 2; backoff > s.opts.maxBackoff {
+			backoff = s.opts.maxBackoff
+		}
+	}
+}
+
+// Subscribe delivers logs to ch until the subscription is cancelled.
+func (s *Subscriber) Subscribe(ch chan<- types.Log) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-quit:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		err := s.Run(ctx, LogHandlerFunc(func(ctx context.Context, log types.Log) error {
+			select {
+			case ch <- log:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}))
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		return err
+	})
+}
+
+// resume restarts from the saved cursor, skipping the logs handled before.
+func (s *Subscriber) resume() error {
+	if s.opts.cursors == nil || s.resumed {
+		return nil
+	}
+	cursor, err := s.opts.cursors.Load(s.cursorKey)
+	if err != nil {
+		return fmt.Errorf("failed to load cursor %s: %w", s.cursorKey, err)
+	}
+	s.resumed = true
+	if cursor == nil {
+		return nil
+	}
+
+	next := cursor.Block
+	s.next = &next
+	s.floor = next
+	for i := uint(0); i <= cursor.Index; i++ {
+		s.seen[logKey{block: cursor.BlockHash, index: i}] = cursor.Block
+	}
+	return nil
+}
+
+type handlerError struct {
+	err error
+}
+
+func (e *handlerError) Error() string {
+	return e.err.Error()
+}
+
+// session subscribes, backfills from s.next and delivers logs until the
+// subscription fails. connected reports whether the subscription was set up.
+func (s *Subscriber) session(ctx context.Context, h LogHandler) (connected bool, err error) {
+	client, err := s.fr.conns.Eth(ctx, s.endpoint())
+	if err != nil {
+		return false, err
+	}
+
+	// subscribe before backfilling so that no log falls in between
+	logs := make(chan types.Log, 128)
+	sub, err := client.SubscribeFilterLogs(ctx, s.query, logs)
+	if errors.Is(err, rpc.ErrNotificationsUnsupported) {
+		return s.poll(ctx, client, h)
+	}
+	if err != nil {
+		return false, wrapRPCError("eth_subscribe", err)
+	}
+	defer sub.Unsubscribe()
+
+	// heads are only needed to count confirmations, nil channels never fire
+	var (
+		heads   chan *headerNumber
+		headErr <-chan error
+	)
+	if s.confirmations > 0 {
+		heads = make(chan *headerNumber, 16)
+		headSub, err := client.Client().EthSubscribe(ctx, heads, "newHeads")
+		if err != nil {
+			return false, wrapRPCError("eth_subscribe", err)
+		}
+		defer headSub.Unsubscribe()
+		headErr = headSub.Err()
+	}
+
+	head, err := client.BlockNumber(ctx)
+	if err != nil {
+		return true, wrapRPCError("eth_blockNumber", err)
+	}
+	if err := s.catchUp(ctx, client, h, head, 0); err != nil {
+		return true, err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return true, ctx.Err()
+		case err := <-sub.Err():
+			if err == nil {
+				err = errors.New("subscription closed")
+			}
+			return true, wrapRPCError("eth_subscribe", err)
+		case err := <-headErr:
+			if err == nil {
+				err = errors.New("subscription closed")
+			}
+			return true, wrapRPCError("eth_subscribe", err)
+		case log := <-logs:
+			if err := s.receive(ctx, h, log); err != nil {
+				return true, err
+			}
+		case header := <-heads:
+			if err := s.confirm(ctx, client, h, uint64(header.Number)); err != nil {
+				return true, err
+			}
+		}
+	}
+}
+
+// poll delivers the logs of new blocks every poll interval until a request
+// fails. As polled logs are never marked removed, the delivered logs of
+// reorged blocks are retracted before their replacements are scanned.
+func (s *Subscriber) poll(ctx context.Context, client *ethclient.Client, h LogHandler) (connected bool, err error) {
+	ticker := time.NewTicker(s.opts.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		head, err := client.BlockNumber(ctx)
+		if err != nil {
+			return connected, wrapRPCError("eth_blockNumber", err)
+		}
+		connected = true
+
+		// the hash is taken before delivering, so that a reorg in between is
+		// detected by the next poll
+		hash, err := canonicalHash(ctx, client, head)
+		if err != nil {
+			return true, err
+		}
+		if err := s.retract(ctx, client, h); err != nil {
+			return true, err
+		}
+		if err := s.catchUp(ctx, client, h, head, s.confirmations); err != nil {
+			return true, err
+		}
+		s.polledHead, s.polledHash = head, hash
+
+		select {
+		case <-ctx.Done():
+			return true, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// catchUp delivers the logs from s.next, minus rescan blocks, up to head and
+// confirms the pending logs at head.
+func (s *Subscriber) catchUp(ctx context.Context, client *ethclient.Client, h LogHandler, head, rescan uint64) error {
+	if s.next == nil {
+		next := head + 1
+		s.next = &next
+		s.floor = next
+	}
+
+	from := s.floor
+	if *s.next > from+rescan {
+		from = *s.next - rescan
+	}
+	if from <= head {
+		query := s.query
+		query.FromBlock = new(big.Int).SetUint64(from)
+		query.ToBlock = new(big.Int).SetUint64(head)
+		past, err := client.FilterLogs(ctx, query)
+		if err != nil {
+			return wrapRPCError("eth_getLogs", err)
+		}
+		for _, log := range past {
+			if err := s.receive(ctx, h, log); err != nil {
+				return err
+			}
+		}
+		s.advance(head + 1)
+	}
+	return s.confirm(ctx, client, h, head)
+}
+
+// retract passes the delivered logs whose block is no longer canonical to
+// receive as removed, if the head of the last poll was replaced, and moves
+// the backfill start back to the first of their blocks.
+func (s *Subscriber) retract(ctx context.Context, client *ethclient.Client, h LogHandler) error {
+	if s.polledHash == (common.Hash{}) {
+		return nil
+	}
+	hash, err := canonicalHash(ctx, client, s.polledHead)
+	if err != nil || hash == s.polledHash {
+		return err
+	}
+	s.polledHash = common.Hash{}
+
+	canonical := map[uint64]common.Hash{}
+	var removed []types.Log
+	for _, log := range s.delivered {
+		hash, ok := canonical[log.BlockNumber]
+		if !ok {
+			if hash, err = canonicalHash(ctx, client, log.BlockNumber); err != nil {
+				return err
+			}
+			canonical[log.BlockNumber] = hash
+		}
+		if hash != log.BlockHash {
+			removed = append(removed, log)
+		}
+	}
+	// retract the most recent logs first, as a removal notification does
+	sort.Slice(removed, func(i, j int) bool {
+		if removed[i].BlockNumber != removed[j].BlockNumber {
+			return removed[i].BlockNumber > removed[j].BlockNumber
+		}
+		return removed[i].Index > removed[j].Index
+	})
+
+	for _, log := range removed {
+		delete(s.delivered, logKey{block: log.BlockHash, index: log.Index})
+		if s.next == nil || log.BlockNumber < *s.next {
+			next := log.BlockNumber
+			s.next = &next
+		}
+		log.Removed = true
+		if err := s.receive(ctx, h, log); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// canonicalHash returns the hash of the canonical block number, zero if the
+// chain is shorter.
+func canonicalHash(ctx context.Context, client *ethclient.Client, number uint64) (common.Hash, error) {
+	var block *struct {
+		Hash common.Hash `json:"hash"`
+	}
+	if err := client.Client().CallContext(ctx, &block, "eth_getBlockByNumber", hexutil.EncodeUint64(number), false); err != nil {
+		return common.Hash{}, wrapRPCError("eth_getBlockByNumber", err)
+	}
+	if block == nil {
+		return common.Hash{}, nil
+	}
+	return block.Hash, nil
+}
+
+// endpoint returns the RPC endpoint to read logs from.
+func (s *Subscriber) endpoint() string {
+	if s.opts.endpoint != "" {
+		return s.opts.endpoint
+	}
+	return s.fr.logsEndpoint()
+}
+
+type headerNumber struct {
+	Number hexutil.Uint64 `json:"number"`
+}
+
+// receive delivers log, or holds it until it is confirmed, unless it was
+// delivered before. Removed logs are retracted.
+func (s *Subscriber) receive(ctx context.Context, h LogHandler, log types.Log) error {
+	key := logKey{block: log.BlockHash, index: log.Index}
+	if log.Removed {
+		if _, ok := s.pending[key]; ok {
+			delete(s.pending, key)
+			return nil
+		}
+		if _, ok := s.seen[key]; !ok {
+			return nil
+		}
+		delete(s.seen, key)
+		delete(s.delivered, key)
+		if r, ok := h.(LogRevertHandler); ok {
+			if err := r.RevertLog(ctx, log); err != nil {
+				return &handlerError{err: err}
+			}
+		}
+		return nil
+	}
+
+	if _, ok := s.seen[key]; ok {
+		return nil
+	}
+	if s.confirmations > 0 {
+		s.pending[key] = log
+		return nil
+	}
+	return s.deliver(ctx, h, log)
+}
+
+// confirm delivers the pending logs with enough confirmations at head, in
+// chain order. Logs whose block is no longer canonical are dropped.
+func (s *Subscriber) confirm(ctx context.Context, client *ethclient.Client, h LogHandler, head uint64) error {
+	var ready []types.Log
+	for _, log := range s.pending {
+		if log.BlockNumber+s.confirmations <= head {
+			ready = append(ready, log)
+		}
+	}
+	sort.Slice(ready, func(i, j int) bool {
+		if ready[i].BlockNumber != ready[j].BlockNumber {
+			return ready[i].BlockNumber < ready[j].BlockNumber
+		}
+		return ready[i].Index < ready[j].Index
+	})
+
+	canonical := map[uint64]common.Hash{}
+	for _, log := range ready {
+		hash, ok := canonical[log.BlockNumber]
+		if !ok {
+			var err error
+			if hash, err = canonicalHash(ctx, client, log.BlockNumber); err != nil {
+				return err
+			}
+			canonical[log.BlockNumber] = hash
+		}
+
+		key := logKey{block: log.BlockHash, index: log.Index}
+		if hash != log.BlockHash {
+			delete(s.pending, key)
+			continue
+		}
+		if err := s.deliver(ctx, h, log); err != nil {
+			return err
+		}
+		delete(s.pending, key)
+	}
+	return nil
+}
+
+// deliver hands log to h and records it as delivered. The cursor is saved
+// once h is done with log and the logs delivered before it.
+func (s *Subscriber) deliver(ctx context.Context, h LogHandler, log types.Log) error {
+	done := s.track(log)
+	if async, ok := h.(AsyncLogHandler); ok {
+		if err := async.HandleLogAsync(ctx, log, done); err != nil {
+			return &handlerError{err: err}
+		}
+	} else {
+		if err := h.HandleLog(ctx, log); err != nil {
+			return &handlerError{err: err}
+		}
+		if err := done(); err != nil {
+			return &handlerError{err: err}
+		}
+	}
+	key := logKey{block: log.BlockHash, index: log.Index}
+	s.seen[key] = log.BlockNumber
+	s.delivered[key] = log
+	// the block of log may still have undelivered logs
+	s.advance(log.BlockNumber)
+	return nil
+}
+
+// track queues log as in flight and returns the function marking it handled.
+func (s *Subscriber) track(log types.Log) func() error {
+	ack := &logAck{log: log}
+	s.ackLock.Lock()
+	s.acks = append(s.acks, ack)
+	s.ackLock.Unlock()
+	return func() error {
+		return s.complete(ack)
+	}
+}
+
+// complete marks ack handled and saves the cursor at the last log of the
+// handled run at the head of the queue, if any.
+func (s *Subscriber) complete(ack *logAck) error {
+	s.ackLock.Lock()
+	defer s.ackLock.Unlock()
+
+	ack.done = true
+	var last *types.Log
+	for len(s.acks) > 0 && s.acks[0].done {
+		last = &s.acks[0].log
+		s.acks = s.acks[1:]
+	}
+	if last == nil || s.opts.cursors == nil {
+		return nil
+	}
+
+	cursor := Cursor{Block: last.BlockNumber, BlockHash: last.BlockHash, Index: last.Index}
+	if err := s.opts.cursors.Save(s.cursorKey, cursor); err != nil {
+		return fmt.Errorf("failed to save cursor %s: %w", s.cursorKey, err)
+	}
+	return nil
+}
+
+// advance moves the backfill start to block next, but not past a pending
+// log, and forgets the delivered logs of older blocks.
+func (s *Subscriber) advance(next uint64) {
+	for _, log := range s.pending {
+		if log.BlockNumber < next {
+			next = log.BlockNumber
+		}
+	}
+	if s.next != nil && next <= *s.next {
+		return
+	}
+	s.next = &next
+	for key, block := range s.seen {
+		if block+seenBlocks < next {
+			delete(s.seen, key)
+			delete(s.delivered, key)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package framework
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	testContract = common.HexToAddress("0x00000000000000000000000000000000000000c0")
+	testTopic    = crypto.Keccak256Hash([]byte("Test(uint256)"))
+)
+
+// fakeChain serves the eth methods a polling Subscriber needs over HTTP.
+type fakeChain struct {
+	lock   sync.Mutex
+	blocks []common.Hash
+	logs   map[common.Hash][]types.Log
+	forks  int
+}
+
+// newFakeChain starts a chain with an empty genesis block and returns the
+// URL of its HTTP endpoint.
+func newFakeChain(t *testing.T) (*fakeChain, string) {
+	chain := &fakeChain{logs: map[common.Hash][]types.Log{}}
+	chain.AddBlock(0)
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", chain); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Stop()
+	})
+	return chain, httpServer.URL
+}
+
+// AddBlock appends a block with n logs and returns its logs.
+func (c *fakeChain) AddBlock(n int) []types.Log {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.addBlock(n)
+}
+
+// Reorg replaces the blocks from number on with one block with n logs.
+func (c *fakeChain) Reorg(number uint64, n int) []types.Log {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.blocks = c.blocks[:number]
+	c.forks++
+	return c.addBlock(n)
+}
+
+func (c *fakeChain) addBlock(n int) []types.Log {
+	number := uint64(len(c.blocks))
+	hash := crypto.Keccak256Hash([]byte(fmt.Sprintf("block %d fork %d", number, c.forks)))
+	c.blocks = append(c.blocks, hash)
+
+	logs := make([]types.Log, n)
+	for i := range logs {
+		logs[i] = types.Log{
+			Address:     testContract,
+			Topics:      []common.Hash{testTopic},
+			Data:        []byte{},
+			BlockNumber: number,
+			BlockHash:   hash,
+			TxHash:      crypto.Keccak256Hash(hash.Bytes(), []byte{byte(i)}),
+			Index:       uint(i),
+		}
+	}
+	c.logs[hash] = logs
+	return logs
+}
+
+func (c *fakeChain) BlockNumber() hexutil.Uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return hexutil.Uint64(len(c.blocks) - 1)
+}
+
+type fakeFilter struct {
+	FromBlock hexutil.Uint64 `json:"fromBlock"`
+	ToBlock   hexutil.Uint64 `json:"toBlock"`
+}
+
+func (c *fakeChain) GetLogs(filter fakeFilter) []types.Log {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	logs := []types.Log{}
+	for n := uint64(filter.FromBlock); n <= uint64(filter.ToBlock) && n < uint64(len(c.blocks)); n++ {
+		logs = append(logs, c.logs[c.blocks[n]]...)
+	}
+	return logs
+}
+
+func (c *fakeChain) GetBlockByNumber(number hexutil.Uint64, _ bool) map[string]interface{} {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if uint64(number) >= uint64(len(c.blocks)) {
+		return nil
+	}
+	return map[string]interface{}{"number": number, "hash": c.blocks[number]}
+}
+
+// newTestFramework returns a framework reading logs from url.
+func newTestFramework(url string) *Framework {
+	return &Framework{
+		config: &Config{Network: &Network{Name: "test", KettleRPC: url}, KettleRPC: url},
+		conns:  NewConnections(),
+	}
+}
+
+// recordingHandler sends the handled and reverted logs to its channels.
+type recordingHandler struct {
+	handled  chan types.Log
+	reverted chan types.Log
+}
+
+func newRecordingHandler() *recordingHandler {
+	return &recordingHandler{handled: make(chan types.Log, 64), reverted: make(chan types.Log, 64)}
+}
+
+func (h *recordingHandler) HandleLog(ctx context.Context, log types.Log) error {
+	h.handled <- log
+	return nil
+}
+
+func (h *recordingHandler) RevertLog(ctx context.Context, log types.Log) error {
+	h.reverted <- log
+	return nil
+}
+
+func expectLog(t *testing.T, ch <-chan types.Log, want types.Log, what string) {
+	t.Helper()
+	select {
+	case got := <-ch:
+		if got.BlockHash != want.BlockHash || got.Index != want.Index {
+			t.Fatalf("%s log %d of block %s, want log %d of block %s", what, got.Index, got.BlockHash.Hex(), want.Index, want.BlockHash.Hex())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no %s log, want log %d of block %s", what, want.Index, want.BlockHash.Hex())
+	}
+}
+
+func TestSubscriberPollRetractsReorgedLogs(t *testing.T) {
+	chain, url := newFakeChain(t)
+	first := chain.AddBlock(1)
+	reorged := chain.AddBlock(2)
+
+	fr := newTestFramework(url)
+	sub := fr.NewSubscriber(ethereum.FilterQuery{Addresses: []common.Address{testContract}},
+		WithStartBlock(1), WithPollInterval(10*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h := newRecordingHandler()
+	errc := make(chan error, 1)
+	go func() { errc <- sub.Run(ctx, h) }()
+
+	expectLog(t, h.handled, first[0], "handled")
+	expectLog(t, h.handled, reorged[0], "handled")
+	expectLog(t, h.handled, reorged[1], "handled")
+
+	replacement := chain.Reorg(2, 1)
+	next := chain.AddBlock(1)
+
+	expectLog(t, h.reverted, reorged[1], "reverted")
+	expectLog(t, h.reverted, reorged[0], "reverted")
+	expectLog(t, h.handled, replacement[0], "handled")
+	expectLog(t, h.handled, next[0], "handled")
+
+	cancel()
+	<-errc
+	select {
+	case log := <-h.handled:
+		t.Errorf("log %d of block %d handled twice", log.Index, log.BlockNumber)
+	case log := <-h.reverted:
+		t.Errorf("log %d of block %d reverted twice", log.Index, log.BlockNumber)
+	default:
+	}
+}
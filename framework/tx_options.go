@@ -0,0 +1,122 @@
+package framework
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// defaultConfidentialGas is the gas limit of confidential requests without WithGas.
+const defaultConfidentialGas = 1000000
+
+type txOptions struct {
+	value      *big.Int
+	gas        uint64
+	gasPrice   *big.Int
+	gasTipCap  *big.Int
+	gasFeeCap  *big.Int
+	nonce      *uint64
+	txType     *uint8
+	accessList types.AccessList
+
+	confirmations uint64
+	rebroadcast   time.Duration
+	bumpAfter     time.Duration
+	bumpPercent   uint64
+}
+
+// TxOption customizes a transaction sent to a contract or to an account.
+// Confidential requests ignore the type, fee caps and access list, and are
+// never rebroadcast nor replaced.
+type TxOption func(*txOptions)
+
+func newTxOptions(opts []TxOption) *txOptions {
+	o := &txOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithValue sends value to the called method, which must be payable.
+func WithValue(value *big.Int) TxOption {
+	return func(o *txOptions) {
+		o.value = value
+	}
+}
+
+// WithGas sets the gas limit instead of the default one.
+func WithGas(gas uint64) TxOption {
+	return func(o *txOptions) {
+		o.gas = gas
+	}
+}
+
+// WithGasPrice sets the gas price instead of the price suggested by the node.
+func WithGasPrice(gasPrice *big.Int) TxOption {
+	return func(o *txOptions) {
+		o.gasPrice = gasPrice
+	}
+}
+
+// WithFeeCaps sets the tip and fee cap of a dynamic fee transaction instead
+// of the ones estimated by SuggestFees. Either may be nil to keep the estimate.
+func WithFeeCaps(gasTipCap, gasFeeCap *big.Int) TxOption {
+	return func(o *txOptions) {
+		o.gasTipCap = gasTipCap
+		o.gasFeeCap = gasFeeCap
+	}
+}
+
+// WithTxType sets the transaction type, one of types.LegacyTxType,
+// types.AccessListTxType and types.DynamicFeeTxType. By default dynamic fee
+// transactions are sent to London chains and legacy ones to the others.
+func WithTxType(txType uint8) TxOption {
+	return func(o *txOptions) {
+		o.txType = &txType
+	}
+}
+
+// WithAccessList attaches an access list to the transaction, which is sent
+// as an access list transaction unless it has dynamic fees.
+func WithAccessList(list types.AccessList) TxOption {
+	return func(o *txOptions) {
+		o.accessList = list
+	}
+}
+
+// WithNonce sets the nonce instead of the pending nonce of the sender,
+// e.g. to replace a transaction stuck in the pool.
+func WithNonce(nonce uint64) TxOption {
+	return func(o *txOptions) {
+		o.nonce = &nonce
+	}
+}
+
+// WithReceiptConfirmations waits until n blocks are built on top of the block
+// including the transaction before returning its receipt.
+func WithReceiptConfirmations(n uint64) TxOption {
+	return func(o *txOptions) {
+		o.confirmations = n
+	}
+}
+
+// WithRebroadcast sends the transaction again every interval while it is
+// pending, and as soon as it is dropped from the pool instead of failing
+// with ErrTxDropped.
+func WithRebroadcast(interval time.Duration) TxOption {
+	return func(o *txOptions) {
+		o.rebroadcast = interval
+	}
+}
+
+// WithFeeBump replaces the transaction with one paying fees raised by percent
+// each time it stayed pending for after. Nodes only accept a replacement
+// raising the fees by 10 percent or more.
+func WithFeeBump(after time.Duration, percent uint64) TxOption {
+	return func(o *txOptions) {
+		o.bumpAfter = after
+		o.bumpPercent = percent
+	}
+}